@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
 )
 
 func newCompletionCommand() *cobra.Command {
@@ -23,7 +28,7 @@ func completionHandler(cmd *cobra.Command, args []string) error {
 
 	switch args[0] {
 	case "bash":
-		err = cmd.Root().GenBashCompletion(os.Stdout)
+		err = cmd.Root().GenBashCompletionV2(os.Stdout, true)
 	case "zsh":
 		err = cmd.Root().GenZshCompletion(os.Stdout)
 	case "fish":
@@ -39,6 +44,192 @@ func completionHandler(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// completionTimeout bounds the AWS calls made while completing a word, so a
+// slow network never hangs the shell
+const completionTimeout = 5 * time.Second
+
+// completionClients returns AWS clients for the profile on the command line
+func completionClients() (*ecs.AWSClients, context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+
+	clients, err := ecs.NewAWSClients(ctx, rootCmd.Flag("profile").Value.String())
+	if err != nil {
+		cancel()
+
+		return nil, nil, nil, err
+	}
+
+	return clients, ctx, cancel, nil
+}
+
+// completeList completes the last item of a comma separated list value
+func completeList(toComplete string, candidates []string) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+
+	completions := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		completions = append(completions, prefix+candidate)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completionError logs the error for `__complete` debugging and completes
+// nothing
+func completionError(err error) ([]string, cobra.ShellCompDirective) {
+	cobra.CompErrorln(err.Error())
+
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func serviceNamesForCompletion() ([]string, error) {
+	clients, ctx, cancel, err := completionClients()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return ecs.ListServiceNames(ctx, clients)
+}
+
+// completeServices completes --service with the cluster/service pairs in the
+// region
+func completeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	services, err := serviceNamesForCompletion()
+	if err != nil {
+		return completionError(err)
+	}
+
+	return services, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeServiceList completes comma separated cluster/service pairs
+func completeServiceList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	services, err := serviceNamesForCompletion()
+	if err != nil {
+		return completionError(err)
+	}
+
+	return completeList(toComplete, services)
+}
+
+// clusterNamesForCompletion returns the clusters running services, taken
+// from the cached service list
+func clusterNamesForCompletion() ([]string, error) {
+	services, err := serviceNamesForCompletion()
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []string
+
+	for _, service := range services {
+		cluster, _, _ := strings.Cut(service, "/")
+		clusters = append(clusters, cluster)
+	}
+
+	slices.Sort(clusters)
+
+	return slices.Compact(clusters), nil
+}
+
+// completeClusters completes a cluster name
+func completeClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	clusters, err := clusterNamesForCompletion()
+	if err != nil {
+		return completionError(err)
+	}
+
+	return clusters, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeClusterList completes comma separated cluster names
+func completeClusterList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	clusters, err := clusterNamesForCompletion()
+	if err != nil {
+		return completionError(err)
+	}
+
+	return completeList(toComplete, clusters)
+}
+
+// completeProfiles completes --profile from the shared AWS config files
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return ecs.ListProfiles(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRevisions completes the revision numbers of the --service's task
+// definition family
+func completeRevisions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	clients, ctx, cancel, err := completionClients()
+	if err != nil {
+		return completionError(err)
+	}
+	defer cancel()
+
+	revisions, err := ecs.ListRevisionNumbers(ctx, clients, cluster, service)
+	if err != nil {
+		return completionError(err)
+	}
+
+	return revisions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeRevisionArgs completes the two revisions of `revisions diff`
+func completeRevisionArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completeRevisions(cmd, args, toComplete)
+}
+
+// completeAgainstRevision completes --against, which also accepts "current"
+// and "latest"
+func completeAgainstRevision(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	revisions, directive := completeRevisions(cmd, args, toComplete)
+
+	return append([]string{ecs.RevisionCurrent, ecs.RevisionLatest}, revisions...), directive | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeRevisionFlag completes --revision, which also accepts "service"
+// and "latest"
+func completeRevisionFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	revisions, directive := completeRevisions(cmd, args, toComplete)
+
+	return append([]string{ecs.RevisionService, ecs.RevisionLatest}, revisions...), directive | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeTaskIDs completes comma separated IDs of the --service's running
+// tasks
+func completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	clients, ctx, cancel, err := completionClients()
+	if err != nil {
+		return completionError(err)
+	}
+	defer cancel()
+
+	taskIDs, err := ecs.ListTaskIDs(ctx, clients, cluster, service)
+	if err != nil {
+		return completionError(err)
+	}
+
+	return completeList(toComplete, taskIDs)
+}
+
 func init() {
 	rootCmd.AddCommand(newCompletionCommand())
 }
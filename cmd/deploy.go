@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
 	"runecs.io/v1/internal/ecs"
+	"runecs.io/v1/internal/utils"
 )
 
 func newDeployCommand() *cobra.Command {
@@ -22,23 +24,222 @@ func newDeployCommand() *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringP("image-tag", "i", "", "docker image tag")
+	cmd.PersistentFlags().String("image", "", "full docker image reference (e.g., registry:5000/app:tag or app@sha256:...)")
+	cmd.PersistentFlags().StringArray("set-env", nil, "set an environment variable (KEY=VALUE, repeatable)")
+	cmd.PersistentFlags().StringArray("unset-env", nil, "remove an environment variable or secret (KEY, repeatable)")
+	cmd.PersistentFlags().StringArray("secret", nil, "set a secret environment variable (KEY=ARN, repeatable)")
+	cmd.PersistentFlags().String("cpu", "", "task CPU units (e.g., 256, 512, 1024)")
+	cmd.PersistentFlags().String("memory", "", "task memory (e.g., 512, 1024, 1GB, 2GB)")
+	cmd.PersistentFlags().String("command", "", "container command")
+	cmd.PersistentFlags().StringP("task-def-file", "f", "", "deploy the task definition in a JSON or YAML file")
+	cmd.PersistentFlags().StringArray("var", nil, "value for a ${KEY} placeholder in the task definition file (KEY=VALUE, repeatable)")
+	cmd.PersistentFlags().Bool("allow-family-change", false, "deploy a task definition file whose family differs from the one the service runs")
+	cmd.PersistentFlags().BoolP("dry-run", "", false, "show the changes without registering a revision or updating the service")
+	cmd.PersistentFlags().String("revision", ecs.RevisionService, "revision to clone: service (the one the service runs), latest or a number")
+
+	_ = cmd.RegisterFlagCompletionFunc("revision", completeRevisionFlag)
 
 	return cmd
 }
 
+// parseKeyValues parses repeated KEY=VALUE flag values into a map
+func parseKeyValues(values []string, flag string) (map[string]string, error) {
+	parsed := make(map[string]string, len(values))
+
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --%s value %q: expected KEY=VALUE", flag, value)
+		}
+
+		parsed[key] = val
+	}
+
+	return parsed, nil
+}
+
+// deployOptionsFromFlags builds the deploy options from the command flags,
+// validating every value.
+func deployOptionsFromFlags(cmd *cobra.Command) (ecs.DeployOptions, error) {
+	opts := ecs.DeployOptions{}
+
+	var err error
+
+	opts.ImageTag, err = cmd.Flags().GetString("image-tag")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get image-tag flag: %w", err)
+	}
+
+	opts.Image, err = cmd.Flags().GetString("image")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get image flag: %w", err)
+	}
+
+	if opts.ImageTag != "" && opts.Image != "" {
+		return opts, errors.New("--image-tag and --image flags cannot be used together")
+	}
+
+	if opts.Image != "" {
+		_, err = ecs.ParseImageReference(opts.Image)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	opts.Revision, err = revisionFlag(cmd)
+	if err != nil {
+		return opts, err
+	}
+
+	setEnv, _ := cmd.Flags().GetStringArray("set-env")
+	opts.SetEnv, err = parseKeyValues(setEnv, "set-env")
+	if err != nil {
+		return opts, err
+	}
+
+	secrets, _ := cmd.Flags().GetStringArray("secret")
+	opts.Secrets, err = parseKeyValues(secrets, "secret")
+	if err != nil {
+		return opts, err
+	}
+
+	opts.UnsetEnv, _ = cmd.Flags().GetStringArray("unset-env")
+
+	for _, name := range opts.UnsetEnv {
+		_, inSetEnv := opts.SetEnv[name]
+		_, inSecrets := opts.Secrets[name]
+
+		if inSetEnv || inSecrets {
+			return opts, fmt.Errorf("variable %s cannot be both set and unset", name)
+		}
+	}
+
+	for name := range opts.SetEnv {
+		if _, ok := opts.Secrets[name]; ok {
+			return opts, fmt.Errorf("variable %s cannot be both a plain variable and a secret", name)
+		}
+	}
+
+	cpu, _ := cmd.Flags().GetString("cpu")
+	if cpu != "" {
+		opts.Cpu, err = utils.ParseCPU(cpu)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	memory, _ := cmd.Flags().GetString("memory")
+	if memory != "" {
+		opts.Memory, err = utils.ParseMemory(memory)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	command, _ := cmd.Flags().GetString("command")
+	if command != "" {
+		opts.Command, err = parseCommandArgs([]string{command})
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// hasTaskDefinitionChanges reports whether any flag changing the cloned task
+// definition beyond its image was given.
+func hasTaskDefinitionChanges(opts ecs.DeployOptions) bool {
+	return len(opts.SetEnv) > 0 || len(opts.UnsetEnv) > 0 || len(opts.Secrets) > 0 ||
+		opts.Cpu != "" || opts.Memory != "" || len(opts.Command) > 0
+}
+
 func deployPreRunE(cmd *cobra.Command, args []string) error {
-	dockerImageTag, err := cmd.Flags().GetString("image-tag")
+	opts, err := deployOptionsFromFlags(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to get image-tag flag: %w", err)
+		return err
 	}
 
-	if dockerImageTag == "" {
-		return errors.New("--image-tag flag is required")
+	taskDefFile, err := cmd.Flags().GetString("task-def-file")
+	if err != nil {
+		return fmt.Errorf("failed to get task-def-file flag: %w", err)
+	}
+
+	if taskDefFile != "" {
+		if hasTaskDefinitionChanges(opts) {
+			return errors.New("--task-def-file cannot be combined with flags that change the task definition, edit the file instead")
+		}
+
+		if cmd.Flags().Changed("revision") {
+			return errors.New("--revision cannot be used with --task-def-file")
+		}
+
+		return nil
+	}
+
+	vars, _ := cmd.Flags().GetStringArray("var")
+	if len(vars) > 0 {
+		return errors.New("--var can only be used with --task-def-file")
+	}
+
+	if cmd.Flags().Changed("allow-family-change") {
+		return errors.New("--allow-family-change can only be used with --task-def-file")
+	}
+
+	if opts.ImageTag == "" && opts.Image == "" && !hasTaskDefinitionChanges(opts) {
+		return errors.New("nothing to deploy: use --image-tag, --image, --task-def-file or one of the task definition change flags")
 	}
 
 	return nil
 }
 
+// taskDefinitionFileVars returns the values for placeholders in the
+// --task-def-file document: --var values, plus --image-tag and --image as
+// IMAGE_TAG and IMAGE.
+func taskDefinitionFileVars(cmd *cobra.Command, opts ecs.DeployOptions) (map[string]string, error) {
+	values, _ := cmd.Flags().GetStringArray("var")
+
+	vars, err := parseKeyValues(values, "var")
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ImageTag != "" {
+		vars["IMAGE_TAG"] = opts.ImageTag
+	}
+
+	if opts.Image != "" {
+		vars["IMAGE"] = opts.Image
+	}
+
+	return vars, nil
+}
+
+// printTaskDefinitionChanges prints the differences between two revisions
+func printTaskDefinitionChanges(cmd *cobra.Command, changes []ecs.TaskDefinitionChange) {
+	if len(changes) == 0 {
+		cmd.Println("No changes to the task definition.")
+
+		return
+	}
+
+	for _, change := range changes {
+		field := change.Field
+		if change.Container != "" {
+			field = change.Container + " " + field
+		}
+
+		switch {
+		case change.Old == "":
+			cmd.Printf("  + %s: %s\n", boldStyle.Render(field), change.New)
+		case change.New == "":
+			cmd.Printf("  - %s: %s\n", boldStyle.Render(field), change.Old)
+		default:
+			cmd.Printf("  ~ %s: %s -> %s\n", boldStyle.Render(field), change.Old, change.New)
+		}
+	}
+}
+
 func deployHandler(cmd *cobra.Command, args []string) error {
 	// Set up context that cancels on interrupt signal for cancellable deploy operations
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -52,9 +253,9 @@ func deployHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize AWS clients: %w", err)
 	}
 
-	dockerImageTag, err := cmd.Flags().GetString("image-tag")
+	opts, err := deployOptionsFromFlags(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to get image-tag flag: %w", err)
+		return err
 	}
 
 	cluster, service, err := parseServiceFlag()
@@ -62,13 +263,45 @@ func deployHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	result, err := ecs.Deploy(ctx, clients, cluster, service, dockerImageTag)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	taskDefFile, _ := cmd.Flags().GetString("task-def-file")
+	allowFamilyChange, _ := cmd.Flags().GetBool("allow-family-change")
+
+	// Show what changes before the revision is registered and the service
+	// updated, so the diff is visible even when the update fails
+	opts.OnChanges = func(previousTaskDefinitionArn string, changes []ecs.TaskDefinitionChange) {
+		cmd.Printf("Changes against %s:\n", previousTaskDefinitionArn)
+		printTaskDefinitionChanges(cmd, changes)
+		cmd.Println()
+	}
+
+	var result *ecs.DeployResult
+
+	if taskDefFile != "" {
+		var vars map[string]string
+
+		vars, err = taskDefinitionFileVars(cmd, opts)
+		if err != nil {
+			return err
+		}
+
+		result, err = ecs.DeployTaskDefinitionFile(ctx, clients, cluster, service, taskDefFile, vars, dryRun, allowFamilyChange, opts.OnChanges)
+	} else {
+		result, err = ecs.Deploy(ctx, clients, cluster, service, opts, dryRun)
+	}
 
 	if err != nil {
 		return fmt.Errorf("deploy failed: %w", err)
 	}
 
-	cmd.Printf("New task revision %s has been created\n", result.TaskDefinitionArn)
+	if result.DryRun {
+		cmd.Printf("Dry run: a new revision with image %s would be registered and deployed to service %s/%s.\n",
+			result.Image, cluster, service)
+
+		return nil
+	}
+
+	cmd.Printf("New task revision %s has been created with image %s\n", result.TaskDefinitionArn, result.Image)
 	cmd.Printf("Service %s has been updated.\n", result.ServiceArn)
 
 	return nil
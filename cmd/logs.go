@@ -14,7 +14,10 @@ import (
 	"runecs.io/v1/internal/ecs"
 )
 
-var boldStyle = lipgloss.NewStyle().Bold(true)
+var (
+	boldStyle    = lipgloss.NewStyle().Bold(true)
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+)
 
 func newLogsCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -24,7 +27,12 @@ func newLogsCommand() *cobra.Command {
 		RunE:                  logsHandler,
 	}
 
-	cmd.PersistentFlags().BoolP("follow", "f", false, "follow log output")
+	cmd.Flags().BoolP("follow", "f", false, "follow log output")
+	cmd.PersistentFlags().String("revision", ecs.RevisionService, "task definition revision whose log configuration is read: service (the one the service runs), latest or a number")
+
+	_ = cmd.RegisterFlagCompletionFunc("revision", completeRevisionFlag)
+
+	cmd.AddCommand(newLogsExportCommand())
 
 	return cmd
 }
@@ -35,6 +43,11 @@ func logsHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	revision, err := revisionFlag(cmd)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
@@ -50,17 +63,17 @@ func logsHandler(cmd *cobra.Command, args []string) error {
 	}
 
 	if follow {
-		return followLogs(cmd, ctx, clients, cluster, service)
+		return followLogs(cmd, ctx, clients, cluster, service, revision)
 	}
 
-	return showLogs(cmd, ctx, clients, cluster, service)
+	return showLogs(cmd, ctx, clients, cluster, service, revision)
 }
 
-func showLogs(cmd *cobra.Command, ctx context.Context, clients *ecs.AWSClients, cluster, service string) error {
+func showLogs(cmd *cobra.Command, ctx context.Context, clients *ecs.AWSClients, cluster, service, revision string) error {
 	cmd.Printf("Fetching logs from the last hour for service %s...\n", boldStyle.Render(cluster+"/"+service))
 
 	oneHourAgo := time.Now().Add(-time.Hour).Unix() * 1000
-	logs, err := ecs.GetServiceLogs(ctx, clients, cluster, service, &oneHourAgo)
+	logs, err := ecs.GetServiceLogs(ctx, clients, cluster, service, revision, &oneHourAgo)
 	if err != nil {
 		return fmt.Errorf("failed to get logs for service %s/%s: %w", cluster, service, err)
 	}
@@ -85,24 +98,34 @@ func showLogs(cmd *cobra.Command, ctx context.Context, clients *ecs.AWSClients,
 	return nil
 }
 
-func followLogs(cmd *cobra.Command, ctx context.Context, clients *ecs.AWSClients, cluster, service string) error {
+func followLogs(cmd *cobra.Command, ctx context.Context, clients *ecs.AWSClients, cluster, service, revision string) error {
 	cmd.Printf("Starting live tail for service %s...\n", boldStyle.Render(cluster+"/"+service))
-	logChan, closeFunc, err := ecs.TailServiceLogs(ctx, clients, cluster, service)
+	tail, err := ecs.TailServiceLogs(ctx, clients, cluster, service, revision)
 	if err != nil {
 		return fmt.Errorf("failed to start tailing logs: %w", err)
 	}
 
-	defer closeFunc()
+	defer tail.Close()
 
 	cmd.Println("Connected. Streaming logs (press Ctrl+C to stop)...")
 
+	notices := tail.Notices
+
 	for {
 		select {
 		case <-ctx.Done():
 			cmd.Println("\nLog stream closed")
 
 			return nil
-		case log, ok := <-logChan:
+		case notice, ok := <-notices:
+			if !ok {
+				notices = nil
+
+				continue
+			}
+
+			cmd.PrintErrf("%s %s\n", warningStyle.Render("Warning:"), notice)
+		case log, ok := <-tail.Logs:
 			if !ok {
 				cmd.Println("\nLog stream closed")
 
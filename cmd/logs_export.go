@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+	"runecs.io/v1/internal/utils"
+)
+
+func newLogsExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "export",
+		Short:                 "Export service logs for a time window to local files",
+		DisableFlagsInUseLine: true,
+		PreRunE:               logsExportPreRunE,
+		RunE:                  logsExportHandler,
+	}
+
+	cmd.Flags().String("since", "24h", "export logs newer than this duration (e.g., 30m, 24h, 7d)")
+	cmd.Flags().String("until", "", "export logs older than this duration (default now)")
+	cmd.Flags().StringP("out", "o", "", "output directory")
+	cmd.Flags().String("format", ecs.LogExportFormatText, "output format (text or ndjson)")
+	cmd.Flags().Bool("gzip", false, "gzip the output files")
+	cmd.Flags().Bool("single-file", false, "write all tasks into a single file instead of one file per task")
+
+	return cmd
+}
+
+func logsExportPreRunE(cmd *cobra.Command, args []string) error {
+	out, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return fmt.Errorf("failed to get out flag: %w", err)
+	}
+
+	if out == "" {
+		return errors.New("--out flag is required")
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to get format flag: %w", err)
+	}
+
+	if format != ecs.LogExportFormatText && format != ecs.LogExportFormatNDJSON {
+		return fmt.Errorf("invalid format %q: must be %s or %s", format, ecs.LogExportFormatText, ecs.LogExportFormatNDJSON)
+	}
+
+	_, err = revisionFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func logsExportHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	out, _ := cmd.Flags().GetString("out")
+	format, _ := cmd.Flags().GetString("format")
+	gzip, _ := cmd.Flags().GetBool("gzip")
+	singleFile, _ := cmd.Flags().GetBool("single-file")
+	revision, _ := cmd.Flags().GetString("revision")
+
+	sinceDuration, err := utils.ParseDuration(since)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	endTime := now
+
+	if until != "" {
+		untilDuration, err := utils.ParseDuration(until)
+		if err != nil {
+			return err
+		}
+
+		endTime = now.Add(-untilDuration)
+	}
+
+	startTime := now.Add(-sinceDuration)
+	if !startTime.Before(endTime) {
+		return errors.New("--since must reach further back than --until")
+	}
+
+	// Set up context that cancels on interrupt signal; the export can be resumed afterwards
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	cmd.Printf("Exporting logs for service %s to %s...\n", boldStyle.Render(cluster+"/"+service), out)
+
+	result, err := ecs.ExportServiceLogs(ctx, clients, cluster, service, ecs.LogExportOptions{
+		OutputDir:  out,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Format:     format,
+		Gzip:       gzip,
+		SingleFile: singleFile,
+		Revision:   revision,
+		Progress: func(events int, bytes int64) {
+			cmd.Printf("\r%d events, %s written", events, humanize.IBytes(uint64(bytes)))
+		},
+	})
+
+	if err != nil {
+		if result != nil {
+			cmd.Printf("\nExport stopped after %d events (%s). Run the same command again to resume.\n",
+				result.Events, humanize.IBytes(uint64(result.Bytes)))
+		}
+
+		return fmt.Errorf("failed to export logs for service %s/%s: %w", cluster, service, err)
+	}
+
+	cmd.Println()
+	cmd.Println()
+
+	for _, file := range result.Files {
+		cmd.Printf("%s: %d events, %s\n", file.Name, file.Events, humanize.IBytes(uint64(file.Bytes)))
+	}
+
+	if len(result.Files) == 0 {
+		cmd.Printf("No logs found in %s with prefix %s\n", result.LogGroup, result.LogStreamPrefix)
+
+		return nil
+	}
+
+	cmd.Printf("\nExported %d events (%s) into %d files\n",
+		result.Events, humanize.IBytes(uint64(result.Bytes)), len(result.Files))
+
+	if result.Resumed {
+		cmd.Println("The export was resumed from an earlier checkpoint.")
+	}
+
+	return nil
+}
@@ -21,27 +21,70 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
 )
 
+// serviceOptionalAnnotation marks commands that check the --service flag
+// themselves because some of their modes do not need it.
+const serviceOptionalAnnotation = "runecs/service-optional"
+
 var rootCmd = &cobra.Command{
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		commandsWithoutService := []string{"completion", "help", "list", "version"}
+		commandsWithoutService := []string{"completion", "help", "list", "version", cobra.ShellCompRequestCmd}
 		serviceValue := cmd.Flag("service").Value.String()
 
-		serviceRequired := !slices.Contains(commandsWithoutService, cmd.Name())
+		serviceRequired := !slices.Contains(commandsWithoutService, cmd.Name()) &&
+			cmd.Annotations[serviceOptionalAnnotation] == ""
 
 		if serviceRequired && serviceValue == "" {
-			return errors.New("--service flag is required for this command")
+			return pickMissingService(cmd, errors.New("--service flag is required for this command"))
 		}
 
 		return nil
 	},
 }
 
+// pickMissingService opens the service picker when --service was left out on
+// a terminal and sets the flag to the chosen service. Non-interactive runs
+// fail with missing instead.
+func pickMissingService(cmd *cobra.Command, missing error) error {
+	if cmd.Flag("service").Value.String() != "" {
+		return nil
+	}
+
+	if !isInteractive() {
+		return missing
+	}
+
+	service, err := pickService(cmd.Flag("profile").Value.String())
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Flags().Set("service", service)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Using --service %s\n", service)
+
+	return nil
+}
+
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().String("service", "", "service name (cluster/service)")
 	rootCmd.PersistentFlags().String("profile", "", "AWS profile to use for credentials")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "ignore the cached service, revision and task lists used by the picker and completion, and refresh them")
+
+	cobra.OnInitialize(func() {
+		if noCache, _ := rootCmd.PersistentFlags().GetBool("no-cache"); noCache {
+			ecs.DisableDiskCache()
+		}
+	})
+
+	_ = rootCmd.RegisterFlagCompletionFunc("service", completeServices)
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
 
 func parseServiceFlag() (string, string, error) {
@@ -64,6 +107,8 @@ func parseServiceFlag() (string, string, error) {
 }
 
 func Execute() {
+	rootCmd.SetArgs(normalizeScaleArgs(os.Args[1:]))
+
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -0,0 +1,154 @@
+// ABOUTME: Interactive fuzzy picker for the --service flag
+// ABOUTME: Lets users choose a service on a terminal when --service is omitted
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"runecs.io/v1/internal/ecs"
+	"runecs.io/v1/internal/utils"
+)
+
+// pickerHeight is the number of matches shown at once
+const pickerHeight = 15
+
+var errPickerCancelled = errors.New("no service selected")
+
+// isInteractive reports whether the user can answer the picker: input comes
+// from a terminal and the picker can draw on one
+func isInteractive() bool {
+	isTerminal := func(fd uintptr) bool {
+		return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+	}
+
+	return isTerminal(os.Stdin.Fd()) && isTerminal(os.Stderr.Fd())
+}
+
+// servicePicker filters the services by a fuzzy query as the user types
+type servicePicker struct {
+	services []string
+	query    string
+	matches  []string
+	cursor   int
+	chosen   string
+}
+
+func newServicePicker(services []string) *servicePicker {
+	return &servicePicker{services: services, matches: services}
+}
+
+func (p *servicePicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *servicePicker) filter() {
+	p.matches = utils.FuzzyFilter(p.query, p.services)
+	p.cursor = 0
+}
+
+func (p *servicePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch key.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return p, tea.Quit
+	case tea.KeyEnter:
+		if p.cursor < len(p.matches) {
+			p.chosen = p.matches[p.cursor]
+		}
+
+		return p, tea.Quit
+	case tea.KeyUp, tea.KeyCtrlP:
+		p.cursor = max(p.cursor-1, 0)
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		p.cursor = min(p.cursor+1, max(len(p.matches)-1, 0))
+	case tea.KeyBackspace:
+		if p.query != "" {
+			runes := []rune(p.query)
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.query += string(key.Runes)
+		p.filter()
+	}
+
+	return p, nil
+}
+
+func (p *servicePicker) View() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n", boldStyle.Render("Select a service:"), p.query)
+
+	// Keep the cursor visible when there are more matches than lines
+	start := max(p.cursor-pickerHeight+1, 0)
+	end := min(start+pickerHeight, len(p.matches))
+
+	for i := start; i < end; i++ {
+		if i == p.cursor {
+			b.WriteString(lipgloss.NewStyle().Reverse(true).Render("> " + p.matches[i]))
+		} else {
+			b.WriteString("  " + p.matches[i])
+		}
+
+		b.WriteString("\n")
+	}
+
+	if len(p.matches) == 0 {
+		b.WriteString("  No matching services.\n")
+	}
+
+	b.WriteString(lipgloss.NewStyle().Faint(true).Render(
+		fmt.Sprintf("%d/%d • ↑/↓ move • enter select • esc cancel", len(p.matches), len(p.services))))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// pickService lets the user choose a service and returns it as
+// cluster/service
+func pickService(profile string) (string, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	services, err := ecs.ListServiceNames(ctx, clients)
+	if err != nil {
+		return "", fmt.Errorf("failed to list services: %w", err)
+	}
+
+	if len(services) == 0 {
+		return "", errors.New("no services found in the region")
+	}
+
+	picker := newServicePicker(services)
+
+	_, err = tea.NewProgram(picker, tea.WithOutput(os.Stderr), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return "", fmt.Errorf("service picker failed: %w", err)
+	}
+
+	if picker.chosen == "" {
+		return "", errPickerCancelled
+	}
+
+	return picker.chosen, nil
+}
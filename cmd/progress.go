@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+const progressBarWidth = 30
+
+// progressBar redraws a single status line such as
+// "Describing task definitions [=====     ] 120/2000". It only draws when
+// the output is a terminal so piped output stays clean.
+type progressBar struct {
+	mu      sync.Mutex
+	out     io.Writer
+	label   string
+	enabled bool
+	drawn   bool
+}
+
+func newProgressBar(out io.Writer, label string) *progressBar {
+	enabled := false
+	if file, ok := out.(*os.File); ok {
+		enabled = isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+	}
+
+	return &progressBar{out: out, label: label, enabled: enabled}
+}
+
+// Update redraws the bar; it is safe to call from several goroutines
+func (p *progressBar) Update(done, total int) {
+	if !p.enabled || total == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	filled := progressBarWidth * done / total
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+
+	fmt.Fprintf(p.out, "\r%s [%s] %d/%d", p.label, bar, done, total)
+	p.drawn = true
+}
+
+// Clear removes the bar so that regular output starts on a clean line
+func (p *progressBar) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
@@ -2,14 +2,18 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
 	"github.com/spf13/cobra"
 	"runecs.io/v1/internal/ecs"
+	"runecs.io/v1/internal/utils"
 )
 
 const (
@@ -22,20 +26,137 @@ func newPruneCommand() *cobra.Command {
 		Use:                   "prune",
 		Short:                 "Deregister active task definitions",
 		DisableFlagsInUseLine: true,
+		PreRunE:               prunePreRunE,
 		RunE:                  pruneHandler,
+		Annotations:           map[string]string{serviceOptionalAnnotation: "true"},
 	}
 
 	cmd.PersistentFlags().BoolP("dry-run", "", false, "dry run")
 	cmd.PersistentFlags().IntP("keep-last", "", defaultLastNumberOfTasks, "keep last N task definitions")
 	cmd.PersistentFlags().IntP("keep-days", "", defaultLastDays, "keep task definitions older than N days")
+	cmd.PersistentFlags().StringArray("keep-tag-pattern", nil, "keep revisions whose image tag matches the glob (e.g., 'v*', repeatable)")
+	cmd.PersistentFlags().Int("keep-per-image", 0, "keep the last N revisions of every distinct image")
+	cmd.PersistentFlags().String("keep-deployed-within", "", "keep revisions that were a service's primary deployment within the duration (e.g., 30d, 12h)")
+	cmd.PersistentFlags().StringSlice("also-check-clusters", nil, "other clusters whose services and tasks may use the same task definition families")
+	cmd.PersistentFlags().Bool("delete-inactive", false, "permanently delete INACTIVE task definitions that the policy does not keep")
+	cmd.PersistentFlags().Bool("all-families", false, "prune every task definition family in the account instead of the service's")
+	cmd.PersistentFlags().String("family-prefix", "", "with --all-families, only prune families starting with this prefix")
+
+	_ = cmd.RegisterFlagCompletionFunc("also-check-clusters", completeClusterList)
 
 	return cmd
 }
 
+func prunePreRunE(cmd *cobra.Command, args []string) error {
+	allFamilies, _ := cmd.Flags().GetBool("all-families")
+	familyPrefix, _ := cmd.Flags().GetString("family-prefix")
+
+	if familyPrefix != "" && !allFamilies {
+		return errors.New("--family-prefix can only be used with --all-families")
+	}
+
+	_, err := pruneOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	if allFamilies {
+		return nil
+	}
+
+	return pickMissingService(cmd, errors.New("--service flag is required unless --all-families is used"))
+}
+
+// pruneOptionsFromFlags builds the retention policy from the command flags
+func pruneOptionsFromFlags(cmd *cobra.Command) (ecs.PruneOptions, error) {
+	opts := ecs.PruneOptions{}
+
+	opts.KeepLast, _ = cmd.Flags().GetInt("keep-last")
+	opts.KeepDays, _ = cmd.Flags().GetInt("keep-days")
+	opts.KeepPerImage, _ = cmd.Flags().GetInt("keep-per-image")
+	opts.KeepTagPatterns, _ = cmd.Flags().GetStringArray("keep-tag-pattern")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.DeleteInactive, _ = cmd.Flags().GetBool("delete-inactive")
+	opts.AlsoCheckClusters, _ = cmd.Flags().GetStringSlice("also-check-clusters")
+
+	if opts.KeepLast < 0 || opts.KeepDays < 0 || opts.KeepPerImage < 0 {
+		return opts, errors.New("--keep-last, --keep-days and --keep-per-image cannot be negative")
+	}
+
+	err := ecs.ValidateTagPatterns(opts.KeepTagPatterns)
+	if err != nil {
+		return opts, err
+	}
+
+	deployedWithin, _ := cmd.Flags().GetString("keep-deployed-within")
+	if deployedWithin != "" {
+		opts.KeepDeployedWithin, err = utils.ParseDuration(deployedWithin)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --keep-deployed-within: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// printPruneFamilySummaries prints the outcome of each family as a table
+func printPruneFamilySummaries(cmd *cobra.Command, result *ecs.PruneResult) {
+	headerStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	var rows [][]string
+
+	for _, summary := range result.FamilySummaries {
+		status := ""
+		if summary.Err != nil {
+			status = warningStyle.Render(summary.Err.Error())
+		}
+
+		rows = append(rows, []string{
+			summary.Family,
+			strconv.Itoa(summary.TotalCount),
+			strconv.Itoa(summary.KeptCount),
+			strconv.Itoa(summary.DeletedCount),
+			strconv.Itoa(summary.SkippedCount),
+			strconv.Itoa(summary.InactiveDeletedCount),
+			status,
+		})
+	}
+
+	deletedHeader := "Deregistered"
+	inactiveHeader := "Inactive Deleted"
+	if result.DryRun {
+		deletedHeader = "To Deregister"
+		inactiveHeader = "Inactive To Delete"
+	}
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			// Right-align the count columns
+			if col >= 1 && col <= 5 {
+				return cellStyle.Align(lipgloss.Right)
+			}
+
+			return cellStyle
+		}).
+		Headers("Family", "Total", "Kept", deletedHeader, "Skipped", inactiveHeader, "Error").
+		Rows(rows...)
+
+	cmd.Println(t)
+}
+
 func pruneHandler(cmd *cobra.Command, args []string) error {
-	keepLastNr, _ := cmd.Flags().GetInt("keep-last")
-	keepDays, _ := cmd.Flags().GetInt("keep-days")
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	opts, err := pruneOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	allFamilies, _ := cmd.Flags().GetBool("all-families")
+	familyPrefix, _ := cmd.Flags().GetString("family-prefix")
 
 	// Set up context that cancels on interrupt signal for cancellable prune operations
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -47,19 +168,57 @@ func pruneHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize AWS clients: %w", err)
 	}
 
+	progress := newProgressBar(cmd.ErrOrStderr(), "Describing task definitions")
+	opts.OnProgress = progress.Update
+
+	if allFamilies {
+		result, err := ecs.PruneAllFamilies(ctx, clients, familyPrefix, opts)
+		progress.Clear()
+		if err != nil {
+			return fmt.Errorf("failed to prune task definition families: %w", err)
+		}
+
+		cmd.Printf("Processed %d task definition families, keeping revisions in use in %d clusters\n",
+			len(result.Families), len(result.CheckedClusters))
+		cmd.Println()
+
+		printPruneWarnings(cmd, result)
+
+		if len(result.FamilySummaries) > 0 {
+			printPruneFamilySummaries(cmd, result)
+			cmd.Println()
+		}
+
+		printPruneDeleteFailures(cmd, result)
+
+		printPruneTotals(cmd, result)
+
+		for _, summary := range result.FamilySummaries {
+			if summary.Err != nil {
+				return errors.New("some task definition families could not be pruned")
+			}
+		}
+
+		return nil
+	}
+
 	cluster, service, err := parseServiceFlag()
 	if err != nil {
 		return err
 	}
-	result, err := ecs.Prune(ctx, clients, cluster, service, keepLastNr, keepDays, dryRun)
+	result, err := ecs.Prune(ctx, clients, cluster, service, opts)
+	progress.Clear()
 	if err != nil {
 		return fmt.Errorf("failed to prune service: %w", err)
 	}
 
 	// Display families being processed
 	cmd.Printf("Processing %d task definition families: %v\n", len(result.Families), result.Families)
+	cmd.Printf("Revisions in use in clusters %v are kept\n", result.CheckedClusters)
 	cmd.Println()
 
+	printPruneWarnings(cmd, result)
+
 	// Create lipgloss style for ARN formatting
 	arnStyle := lipgloss.NewStyle().Bold(true)
 
@@ -79,12 +238,29 @@ func pruneHandler(cmd *cobra.Command, args []string) error {
 			}
 		case "skipped":
 			cmd.Printf("Task definition %s skipped: %s\n", arnStyle.Render(task.Arn), task.Reason)
+		case "deleted-inactive":
+			if result.DryRun {
+				cmd.Printf("Inactive task definition %s would be deleted\n", arnStyle.Render(task.Arn))
+			} else {
+				cmd.Printf("Inactive task definition %s was deleted\n", arnStyle.Render(task.Arn))
+			}
+		case "kept-inactive":
+			cmd.Printf("Inactive task definition %s created %d days ago was kept (%s)\n",
+				arnStyle.Render(task.Arn), task.DaysOld, task.Reason)
 		}
 	}
 
+	printPruneDeleteFailures(cmd, result)
+
 	cmd.Println()
 
-	// Display summary
+	printPruneTotals(cmd, result)
+
+	return nil
+}
+
+// printPruneTotals prints the number of processed and deregistered revisions
+func printPruneTotals(cmd *cobra.Command, result *ecs.PruneResult) {
 	if result.DryRun {
 		cmd.Printf("Total of %d task definitions. Will delete %d definitions.\n",
 			result.TotalCount, result.DeletedCount)
@@ -93,7 +269,44 @@ func pruneHandler(cmd *cobra.Command, args []string) error {
 			result.TotalCount, result.DeletedCount)
 	}
 
-	return nil
+	cmd.Printf("Kept %d definitions, skipped %d.\n", result.KeptCount, result.SkippedCount)
+
+	if result.InactiveDeletedCount > 0 || len(result.DeleteFailures) > 0 {
+		if result.DryRun {
+			cmd.Printf("Will delete %d inactive definitions.\n", result.InactiveDeletedCount)
+		} else {
+			cmd.Printf("Deleted %d inactive definitions, %d failed.\n",
+				result.InactiveDeletedCount, len(result.DeleteFailures))
+		}
+	}
+}
+
+// printPruneWarnings prints the sources of in-use revisions that could not
+// be checked
+func printPruneWarnings(cmd *cobra.Command, result *ecs.PruneResult) {
+	if len(result.Warnings) == 0 {
+		return
+	}
+
+	for _, warning := range result.Warnings {
+		cmd.Println(warningStyle.Render("Warning: " + warning))
+	}
+
+	cmd.Println()
+}
+
+// printPruneDeleteFailures prints the INACTIVE task definitions that could
+// not be deleted
+func printPruneDeleteFailures(cmd *cobra.Command, result *ecs.PruneResult) {
+	if len(result.DeleteFailures) == 0 {
+		return
+	}
+
+	for _, failure := range result.DeleteFailures {
+		cmd.Println(warningStyle.Render(fmt.Sprintf("Failed to delete %s: %s", failure.Arn, failure.Reason)))
+	}
+
+	cmd.Println()
 }
 
 func init() {
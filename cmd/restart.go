@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"runecs.io/v1/internal/ecs"
+	"runecs.io/v1/internal/utils"
 )
 
 func newRestartCommand() *cobra.Command {
@@ -17,16 +20,94 @@ func newRestartCommand() *cobra.Command {
 		Use:                   "restart",
 		Short:                 "Restart the service",
 		DisableFlagsInUseLine: true,
+		PreRunE:               restartPreRunE,
 		RunE:                  restartHandler,
 	}
 
 	cmd.PersistentFlags().BoolP("kill", "", false, "Stops running tasks, ECS starts a new one if the health check is properly set")
+	cmd.Flags().Int("batch-size", 0, "with --kill, stop this many tasks per wave and wait for healthy replacements after each wave (0 stops all at once)")
+	cmd.Flags().Duration("interval", 0, "with --batch-size, pause between waves after the replacements are healthy")
+	cmd.Flags().Duration("health-timeout", 10*time.Minute, "with --batch-size, how long to wait for the replacements of a wave")
+	cmd.Flags().StringSlice("task", nil, "stop only these tasks (IDs or ARNs), implies --kill")
+	cmd.Flags().String("older-than", "", "stop only tasks started longer ago than the duration (e.g., 12h, 2d), implies --kill")
+	cmd.Flags().Bool("unhealthy", false, "stop only tasks whose container health checks fail, implies --kill")
+	cmd.Flags().String("reason", "", "reason recorded on the stopped tasks")
+	cmd.Flags().String("revision", ecs.RevisionService, "task definition revision to deploy: service (the one the service runs), latest or a number")
+
+	_ = cmd.RegisterFlagCompletionFunc("task", completeTaskIDs)
+	_ = cmd.RegisterFlagCompletionFunc("revision", completeRevisionFlag)
 
 	return cmd
 }
 
+// restartOptionsFromFlags collects the restart options. Selecting tasks
+// implies --kill.
+func restartOptionsFromFlags(cmd *cobra.Command) (ecs.RestartOptions, error) {
+	opts := ecs.RestartOptions{}
+
+	opts.Kill, _ = cmd.Flags().GetBool("kill")
+	opts.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+	opts.Interval, _ = cmd.Flags().GetDuration("interval")
+	opts.HealthTimeout, _ = cmd.Flags().GetDuration("health-timeout")
+	opts.TaskIDs, _ = cmd.Flags().GetStringSlice("task")
+	opts.Unhealthy, _ = cmd.Flags().GetBool("unhealthy")
+	opts.StopReason, _ = cmd.Flags().GetString("reason")
+
+	var err error
+
+	olderThan, _ := cmd.Flags().GetString("older-than")
+	if olderThan != "" {
+		opts.OlderThan, err = utils.ParseDuration(olderThan)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+
+	if len(opts.TaskIDs) > 0 || opts.OlderThan > 0 || opts.Unhealthy {
+		opts.Kill = true
+	}
+
+	opts.Revision, err = revisionFlag(cmd)
+	if err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+func restartPreRunE(cmd *cobra.Command, args []string) error {
+	opts, err := restartOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	if opts.BatchSize < 0 {
+		return errors.New("--batch-size must not be negative")
+	}
+
+	if opts.Interval < 0 || opts.HealthTimeout <= 0 {
+		return errors.New("--interval must not be negative and --health-timeout must be positive")
+	}
+
+	for _, name := range []string{"batch-size", "interval", "health-timeout", "reason"} {
+		if cmd.Flags().Changed(name) && !opts.Kill {
+			return fmt.Errorf("--%s requires --kill or a task selector", name)
+		}
+	}
+
+	// Stopped tasks are replaced with the revision the service runs
+	if cmd.Flags().Changed("revision") && opts.Kill {
+		return errors.New("--revision cannot be used with --kill or a task selector")
+	}
+
+	return nil
+}
+
 func restartHandler(cmd *cobra.Command, args []string) error {
-	kill, _ := cmd.Flags().GetBool("kill")
+	opts, err := restartOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Set up context that cancels on interrupt signal for cancellable restart operations
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -42,24 +123,75 @@ func restartHandler(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	result, err := ecs.Restart(ctx, clients, cluster, service, kill)
+	opts.OnProgress = func(event ecs.RestartEvent) {
+		printRestartEvent(cmd, event)
+	}
+
+	result, err := ecs.Restart(ctx, clients, cluster, service, opts)
+	if result != nil {
+		printStopFailures(cmd, result.StopFailures)
+	}
+
 	if err != nil {
 		return fmt.Errorf("restart failed: %w", err)
 	}
 
-	if result.Method == "kill" {
-		for _, stoppedTask := range result.StoppedTasks {
-			cmd.Printf("Stopped task %s started %s\n", stoppedTask.TaskArn, humanize.Time(stoppedTask.StartedAt))
-		}
-	} else {
+	if result.Method == "kill" && len(result.StoppedTasks) == 0 && len(result.StopFailures) == 0 {
+		cmd.Println("No tasks matched.")
+
+		return nil
+	}
+
+	if result.Method != "kill" {
 		cmd.Printf("Service %s restarted by starting new tasks using task definition %s.\n", service, result.TaskDefinition)
 	}
 
+	if len(result.StopFailures) > 0 {
+		return fmt.Errorf("failed to stop %d of %d tasks", len(result.StopFailures), len(result.StopFailures)+len(result.StoppedTasks))
+	}
+
 	cmd.Println("Done.")
 
 	return nil
 }
 
+// printRestartEvent prints the tasks stopped by a wave, or that the
+// replacements of the wave are healthy
+func printRestartEvent(cmd *cobra.Command, event ecs.RestartEvent) {
+	prefix := ""
+	if event.Waves > 1 {
+		prefix = fmt.Sprintf("Wave %d/%d: ", event.Wave, event.Waves)
+	}
+
+	if event.Healthy {
+		if prefix == "" {
+			cmd.Println("Replacement tasks are running and healthy")
+
+			return
+		}
+
+		cmd.Printf("%sreplacement tasks are running and healthy\n", prefix)
+
+		return
+	}
+
+	for _, stoppedTask := range event.Stopped {
+		if stoppedTask.StartedAt.IsZero() {
+			cmd.Printf("%sStopped task %s\n", prefix, stoppedTask.TaskArn)
+
+			continue
+		}
+
+		cmd.Printf("%sStopped task %s started %s\n", prefix, stoppedTask.TaskArn, humanize.Time(stoppedTask.StartedAt))
+	}
+}
+
+func printStopFailures(cmd *cobra.Command, failures []ecs.StopTaskFailure) {
+	for _, failure := range failures {
+		cmd.Println(warningStyle.Render(fmt.Sprintf("Failed to stop task %s: %v", failure.TaskArn, failure.Err)))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(newRestartCommand())
 }
@@ -9,11 +9,13 @@ import (
 	"os"
 	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
 	"github.com/spf13/cobra"
 	"runecs.io/v1/internal/ecs"
 )
@@ -21,18 +23,32 @@ import (
 func newRevisionsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                   "revisions",
-		Short:                 "List active task definitions",
+		Short:                 "List task definition revisions",
 		DisableFlagsInUseLine: true,
 		RunE:                  revisionsHandler,
 	}
 
-	cmd.PersistentFlags().IntP("last", "", 0, "last N revisions")
+	cmd.Flags().IntP("last", "", 0, "last N revisions")
+	cmd.Flags().BoolP("inactive", "", false, "include deregistered (INACTIVE) revisions")
+
+	cmd.AddCommand(newRevisionsDiffCommand())
 
 	return cmd
 }
 
+// revisionFlag returns the validated --revision flag
+func revisionFlag(cmd *cobra.Command) (string, error) {
+	revision, err := cmd.Flags().GetString("revision")
+	if err != nil {
+		return "", fmt.Errorf("failed to get revision flag: %w", err)
+	}
+
+	return revision, ecs.ValidateRevision(revision)
+}
+
 func revisionsHandler(cmd *cobra.Command, args []string) error {
 	revNr, _ := cmd.Flags().GetInt("last")
+	includeInactive, _ := cmd.Flags().GetBool("inactive")
 
 	// Set up context that cancels on interrupt signal for cancellable revisions operations
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -48,7 +64,7 @@ func revisionsHandler(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	result, err := ecs.Revisions(ctx, clients, cluster, service, revNr)
+	result, err := ecs.Revisions(ctx, clients, cluster, service, revNr, includeInactive)
 	if err != nil {
 		return fmt.Errorf("failed to get revisions: %w", err)
 	}
@@ -60,28 +76,89 @@ func revisionsHandler(cmd *cobra.Command, args []string) error {
 
 	// Create lipgloss style for date formatting
 	dateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
-	boldStyle := lipgloss.NewStyle().Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	var rows [][]string
 
-	// Print revisions line by line
 	for _, revision := range result.Revisions {
 		// Use proper Go time formatting for date and time without seconds
 		formattedDate := dateStyle.Render(revision.CreatedAt.Local().Format(time.DateTime)[:16])
 
-		// Split DockerURI to extract and style the tag
-		dockerParts := strings.Split(revision.DockerURI, ":")
-		if len(dockerParts) >= 2 {
-			repo := strings.Join(dockerParts[:len(dockerParts)-1], ":")
-			tag := dockerParts[len(dockerParts)-1]
-			styledTag := boldStyle.Render(tag)
-			cmd.Printf("%s: %s:%s\n", formattedDate, repo, styledTag)
-		} else {
-			cmd.Printf("%s: %s\n", formattedDate, revision.DockerURI)
-		}
+		rows = append(rows, []string{
+			strconv.Itoa(int(revision.Revision)),
+			formattedDate,
+			formatRevisionImage(revision.DockerURI),
+			revision.Cpu,
+			revision.Memory,
+			revision.Status,
+			revision.RegisteredBy,
+			formatRevisionDeployments(revision.Deployments, service),
+		})
+	}
+
+	if len(rows) == 0 {
+		cmd.Println("No revisions found.")
+
+		return nil
 	}
 
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			// Right-align Revision (col 0), CPU (col 3) and Memory (col 4) columns
+			if col == 0 || col == 3 || col == 4 {
+				return cellStyle.Align(lipgloss.Right)
+			}
+
+			return cellStyle
+		}).
+		Headers("Revision", "Created", "Image", "CPU", "Memory", "Status", "Registered By", "Deployed").
+		Rows(rows...)
+
+	cmd.Println(t)
+
 	return nil
 }
 
+// formatRevisionImage styles the tag or digest of an image reference
+func formatRevisionImage(dockerURI string) string {
+	image, err := ecs.ParseImageReference(dockerURI)
+	if err != nil || (image.Tag == "" && image.Digest == "") {
+		return dockerURI
+	}
+
+	styled := image.Name()
+	if image.Tag != "" {
+		styled += ":" + boldStyle.Render(image.Tag)
+	}
+	if image.Digest != "" {
+		styled += "@" + boldStyle.Render(image.Digest)
+	}
+
+	return styled
+}
+
+// formatRevisionDeployments lists the services deploying a revision, with the
+// service being inspected in bold.
+func formatRevisionDeployments(deployments []ecs.RevisionDeployment, service string) string {
+	names := make([]string, 0, len(deployments))
+
+	for _, deployment := range deployments {
+		name := fmt.Sprintf("%s (%s)", deployment.Service, strings.ToLower(deployment.Status))
+		if deployment.Service == service {
+			name = boldStyle.Render(name)
+		}
+
+		names = append(names, name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
 func init() {
 	rootCmd.AddCommand(newRevisionsCommand())
 }
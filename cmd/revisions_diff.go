@@ -0,0 +1,73 @@
+// ABOUTME: Command-line interface for comparing ECS task definition revisions
+// ABOUTME: Prints the differences between two revisions of the service's family
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+func newRevisionsDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "diff <revision> [revision]",
+		Short:                 "Show the differences between two task definition revisions",
+		Args:                  cobra.RangeArgs(1, 2),
+		DisableFlagsInUseLine: true,
+		ValidArgsFunction:     completeRevisionArgs,
+		RunE:                  revisionsDiffHandler,
+	}
+
+	cmd.Flags().String("against", ecs.RevisionCurrent, "revision to compare with when only one is given (number, \"current\" or \"latest\")")
+
+	_ = cmd.RegisterFlagCompletionFunc("against", completeAgainstRevision)
+
+	return cmd
+}
+
+func revisionsDiffHandler(cmd *cobra.Command, args []string) error {
+	against, _ := cmd.Flags().GetString("against")
+
+	from := args[0]
+	to := against
+
+	if len(args) == 2 {
+		if cmd.Flags().Changed("against") {
+			return errors.New("--against cannot be used with two revisions")
+		}
+
+		to = args[1]
+	}
+
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for cancellable diff operations
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	result, err := ecs.DiffRevisions(ctx, clients, cluster, service, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to compare revisions: %w", err)
+	}
+
+	cmd.Printf("Changes from %s to %s:\n", boldStyle.Render(result.FromTaskDefinitionArn), boldStyle.Render(result.ToTaskDefinitionArn))
+	printTaskDefinitionChanges(cmd, result.Changes)
+
+	return nil
+}
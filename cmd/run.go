@@ -29,6 +29,9 @@ func newRunCommand() *cobra.Command {
 	cmd.PersistentFlags().StringP("image-tag", "i", "", "docker image tag")
 	cmd.PersistentFlags().StringP("cpu", "c", "", "CPU override for task (e.g., 256, 512, 1024)")
 	cmd.PersistentFlags().StringP("memory", "m", "", "memory override for task (e.g., 512, 1024, 1GB, 2GB)")
+	cmd.PersistentFlags().String("revision", ecs.RevisionService, "task definition revision to run: service (the one the service runs), latest or a number")
+
+	_ = cmd.RegisterFlagCompletionFunc("revision", completeRevisionFlag)
 
 	return cmd
 }
@@ -52,6 +55,11 @@ func runHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	revision, err := revisionFlag(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -101,7 +109,7 @@ func runHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error parsing command arguments: %w", err)
 	}
 
-	result, err := ecs.Execute(ctx, clients, cluster, service, parsedArgs, execWait, dockerImageTag, cpuOverride, memoryOverride)
+	result, err := ecs.Execute(ctx, clients, cluster, service, parsedArgs, execWait, dockerImageTag, cpuOverride, memoryOverride, revision)
 	if err != nil {
 		return fmt.Errorf("failed to execute command: %w", err)
 	}
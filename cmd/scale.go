@@ -19,62 +19,273 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
+	"regexp"
+	"slices"
+	"strings"
 	"syscall"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"runecs.io/v1/internal/ecs"
 )
 
 func newScaleCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                   "scale <value>",
-		Short:                 "Scale the number of running tasks for a service",
+		Use:   "scale <value>",
+		Short: "Scale the number of running tasks for a service",
+		Long: `Scale the number of running tasks for a service.
+
+The value is a task count (5), a change of the current count (+2, -1) or a
+multiple of it (x2, x0.5). Scaling several services with --services or
+--cluster --all remembers their previous counts so "scale restore" can bring
+them back.`,
 		Args:                  cobra.ExactArgs(1),
 		DisableFlagsInUseLine: true,
+		Annotations:           map[string]string{serviceOptionalAnnotation: "true"},
 		PreRunE:               scalePreRunE,
 		RunE:                  scaleHandler,
 	}
 
+	cmd.Flags().Bool("allow-zero", false, "allow scaling the service to 0 tasks")
+	cmd.Flags().Bool("update-autoscaling-bounds", false, "adjust the auto scaling min/max capacity so the new count is kept")
+	cmd.Flags().StringSlice("services", nil, "scale several services (cluster/service,...) and remember their counts")
+	cmd.Flags().String("cluster", "", "cluster whose services --all scales")
+	cmd.Flags().Bool("all", false, "scale every service in --cluster and remember their counts")
+
+	_ = cmd.RegisterFlagCompletionFunc("services", completeServiceList)
+	_ = cmd.RegisterFlagCompletionFunc("cluster", completeClusters)
+
+	cmd.AddCommand(newScaleScheduleCommand())
+	cmd.AddCommand(newScalePolicyCommand())
+	cmd.AddCommand(newScaleRestoreCommand())
+
 	return cmd
 }
 
+func newScaleRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "restore",
+		Short:                 "Restore the task counts remembered by a bulk scale",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Annotations:           map[string]string{serviceOptionalAnnotation: "true"},
+		RunE:                  scaleRestoreHandler,
+	}
+
+	cmd.Flags().StringSlice("services", nil, "restore only these services (cluster/service,...)")
+	cmd.Flags().String("cluster", "", "restore only the services of this cluster")
+
+	_ = cmd.RegisterFlagCompletionFunc("services", completeServiceList)
+	_ = cmd.RegisterFlagCompletionFunc("cluster", completeClusters)
+
+	return cmd
+}
+
+// negativeCountPattern matches relative scale values such as -1, which pflag
+// would otherwise take for shorthand flags.
+var negativeCountPattern = regexp.MustCompile(`^-\d+$`)
+
+// normalizeScaleArgs moves a negative scale value behind "--" so that
+// "runecs scale -1" works without the user escaping it.
+func normalizeScaleArgs(args []string) []string {
+	if slices.Contains(args, "--") {
+		return args
+	}
+
+	valueFlags := []string{"--service", "--profile", "--services", "--cluster"}
+	command := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case slices.Contains(valueFlags, arg):
+			i++
+		case negativeCountPattern.MatchString(arg):
+			if command != "scale" {
+				return args
+			}
+
+			normalized := slices.Concat(args[:i], args[i+1:], []string{"--", arg})
+
+			return normalized
+		case strings.HasPrefix(arg, "-"):
+		case command == "":
+			command = arg
+		}
+	}
+
+	return args
+}
+
+// parseServiceRefs parses cluster/service names
+func parseServiceRefs(values []string) ([]ecs.ServiceRef, error) {
+	refs := make([]ecs.ServiceRef, 0, len(values))
+
+	for _, value := range values {
+		cluster, service, ok := strings.Cut(value, "/")
+		if !ok || cluster == "" || service == "" || strings.Contains(service, "/") {
+			return nil, fmt.Errorf("invalid service name %s, expected cluster/service", value)
+		}
+
+		refs = append(refs, ecs.ServiceRef{Cluster: cluster, Service: service})
+	}
+
+	return refs, nil
+}
+
 func scalePreRunE(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
 		return errors.New("scale command requires exactly one argument: the desired task count")
 	}
 
-	value64, err := strconv.ParseInt(args[0], 10, 32)
+	change, err := ecs.ParseScaleChange(args[0])
 	if err != nil {
-		return fmt.Errorf("invalid scale value: %w", err)
+		return err
 	}
-	value := int(value64)
-
-	const minTaskCount = 1
 
 	const maxTaskCount = 1000
 
-	if value < minTaskCount || value > maxTaskCount {
-		return fmt.Errorf("scale value must be between %d and %d", minTaskCount, maxTaskCount)
+	if change.Kind == ecs.ScaleAbsolute {
+		allowZero, _ := cmd.Flags().GetBool("allow-zero")
+		if change.Count == 0 && !allowZero {
+			return errors.New("scaling to 0 stops the service, use --allow-zero to confirm")
+		}
+
+		if change.Count < 0 || change.Count > maxTaskCount {
+			return fmt.Errorf("scale value must be between 0 and %d", maxTaskCount)
+		}
 	}
 
-	return nil
+	services, _ := cmd.Flags().GetStringSlice("services")
+	cluster, _ := cmd.Flags().GetString("cluster")
+	all, _ := cmd.Flags().GetBool("all")
+
+	if all != (cluster != "") {
+		return errors.New("--cluster and --all must be used together")
+	}
+
+	modesError := errors.New("use exactly one of --service, --services or --cluster with --all")
+
+	// Scaling a single service is the default, so ask for it like the
+	// commands that always need --service
+	if len(services) == 0 && !all {
+		err = pickMissingService(cmd, modesError)
+		if err != nil {
+			return err
+		}
+	}
+
+	single := rootCmd.Flag("service").Value.String() != ""
+
+	modes := 0
+	for _, set := range []bool{single, len(services) > 0, all} {
+		if set {
+			modes++
+		}
+	}
+
+	if modes != 1 {
+		return modesError
+	}
+
+	_, err = parseServiceRefs(services)
+
+	return err
 }
 
 func scaleHandler(cmd *cobra.Command, args []string) error {
-	value64, err := strconv.ParseInt(args[0], 10, 32)
+	change, err := ecs.ParseScaleChange(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
 	if err != nil {
-		return fmt.Errorf("invalid scale value: %w", err)
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	allowZero, _ := cmd.Flags().GetBool("allow-zero")
+	updateBounds, _ := cmd.Flags().GetBool("update-autoscaling-bounds")
+	opts := ecs.ScaleOptions{
+		AllowZero:               allowZero,
+		UpdateAutoScalingBounds: updateBounds,
+	}
+
+	if rootCmd.Flag("service").Value.String() == "" {
+		return scaleServices(ctx, cmd, clients, change, opts)
 	}
-	value := int32(value64)
 
 	cluster, service, err := parseServiceFlag()
 	if err != nil {
 		return err
 	}
 
-	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	result, err := ecs.Scale(ctx, clients, cluster, service, change, opts)
+	if err != nil {
+		return fmt.Errorf("failed to scale service: %w", err)
+	}
+
+	printScaleResult(cmd, result)
+
+	return nil
+}
+
+// scaleServices scales the services of --services or --cluster --all and
+// remembers their previous counts for "scale restore".
+func scaleServices(ctx context.Context, cmd *cobra.Command, clients *ecs.AWSClients, change ecs.ScaleChange, opts ecs.ScaleOptions) error {
+	statePath, err := ecs.DefaultScaleStatePath()
+	if err != nil {
+		return err
+	}
+
+	values, _ := cmd.Flags().GetStringSlice("services")
+	services, err := parseServiceRefs(values)
+	if err != nil {
+		return err
+	}
+
+	if cluster, _ := cmd.Flags().GetString("cluster"); cluster != "" {
+		services, err = ecs.ListClusterServices(ctx, clients, cluster)
+		if err != nil {
+			return fmt.Errorf("failed to list services: %w", err)
+		}
+	}
+
+	if len(services) == 0 {
+		cmd.Println("No services found.")
+
+		return nil
+	}
+
+	result := ecs.ScaleServices(ctx, clients, services, change, opts)
+
+	err = ecs.RememberScaleState(ctx, clients, statePath, result.Results)
+	if err != nil {
+		return fmt.Errorf("failed to remember previous task counts: %w", err)
+	}
+
+	return printBulkScaleResult(cmd, result)
+}
+
+func scaleRestoreHandler(cmd *cobra.Command, args []string) error {
+	values, _ := cmd.Flags().GetStringSlice("services")
+	services, err := parseServiceRefs(values)
+	if err != nil {
+		return err
+	}
+
+	cluster, _ := cmd.Flags().GetString("cluster")
+
+	statePath, err := ecs.DefaultScaleStatePath()
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
@@ -84,18 +295,59 @@ func scaleHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize AWS clients: %w", err)
 	}
 
-	result, err := ecs.Scale(ctx, clients, cluster, service, value)
+	match := func(ref ecs.ServiceRef) bool {
+		if cluster != "" && ref.Cluster != cluster {
+			return false
+		}
+
+		return len(services) == 0 || slices.Contains(services, ref)
+	}
+
+	result, err := ecs.RestoreScale(ctx, clients, statePath, match)
 	if err != nil {
-		return fmt.Errorf("failed to scale service: %w", err)
+		return fmt.Errorf("failed to restore task counts: %w", err)
 	}
 
-	// Create lipgloss style for service name formatting
-	boldStyle := lipgloss.NewStyle().Bold(true)
+	if len(result.Results) == 0 && len(result.Failures) == 0 {
+		cmd.Println("No remembered task counts to restore.")
+
+		return nil
+	}
+
+	return printBulkScaleResult(cmd, result)
+}
+
+func printScaleResult(cmd *cobra.Command, result *ecs.ScaleResult) {
+	if result.UpdatedAutoScaling != nil {
+		cmd.Printf("Auto scaling bounds changed from %d-%d to %d-%d tasks\n",
+			result.AutoScaling.MinCapacity, result.AutoScaling.MaxCapacity,
+			result.UpdatedAutoScaling.MinCapacity, result.UpdatedAutoScaling.MaxCapacity)
+	}
 
 	cmd.Printf("Service %s scaled from %d to %d tasks\n",
 		boldStyle.Render(fmt.Sprintf("%s/%s", result.ClusterName, result.ServiceName)),
 		result.PreviousDesiredCount, result.NewDesiredCount)
 
+	for _, warning := range result.Warnings {
+		cmd.Println(warningStyle.Render("Warning: " + warning))
+	}
+}
+
+// printBulkScaleResult prints every scaled service and the failures, and
+// returns an error when any service failed.
+func printBulkScaleResult(cmd *cobra.Command, result *ecs.BulkScaleResult) error {
+	for i := range result.Results {
+		printScaleResult(cmd, &result.Results[i])
+	}
+
+	for _, failure := range result.Failures {
+		cmd.Println(warningStyle.Render(fmt.Sprintf("Failed to scale %s: %v", failure.ServiceRef, failure.Err)))
+	}
+
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("failed to scale %d of %d services", len(result.Failures), len(result.Failures)+len(result.Results))
+	}
+
 	return nil
 }
 
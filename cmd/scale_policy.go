@@ -0,0 +1,126 @@
+// ABOUTME: Command-line interface for inspecting ECS service auto scaling
+// ABOUTME: Prints the scaling policies of the service and the state of their alarms
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+func newScalePolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Inspect the service's auto scaling policies",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:                   "show",
+		Short:                 "Show the auto scaling policies and alarms of the service",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE:                  scalePolicyShowHandler,
+	})
+
+	return cmd
+}
+
+// alarmStateStyle colors an alarm state: red when in alarm, yellow when
+// there is not enough data, default otherwise
+func alarmStateStyle(state string) lipgloss.Style {
+	switch state {
+	case "ALARM":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	case "INSUFFICIENT_DATA":
+		return warningStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+func formatSeconds(value *int32) string {
+	if value == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(int(*value)) + "s"
+}
+
+func scalePolicyShowHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	result, err := ecs.DescribeScalingPolicies(ctx, clients, cluster, service)
+	if err != nil {
+		return fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+
+	name := boldStyle.Render(fmt.Sprintf("%s/%s", cluster, service))
+
+	if result.Bounds == nil {
+		cmd.Printf("Service %s is not managed by auto scaling.\n", name)
+
+		return nil
+	}
+
+	cmd.Printf("Service %s scales between %d and %d tasks\n", name, result.Bounds.MinCapacity, result.Bounds.MaxCapacity)
+
+	if len(result.Policies) == 0 {
+		cmd.Println("No scaling policies found.")
+
+		return nil
+	}
+
+	for _, policy := range result.Policies {
+		cmd.Println()
+		cmd.Printf("%s (%s)\n", boldStyle.Render(policy.Name), policy.Type)
+
+		if policy.Metric != "" {
+			cmd.Printf("  Metric: %s\n", policy.Metric)
+		}
+
+		if policy.TargetValue != nil {
+			cmd.Printf("  Target: %s\n", strconv.FormatFloat(*policy.TargetValue, 'f', -1, 64))
+		}
+
+		if policy.ScaleInCooldown != nil || policy.ScaleOutCooldown != nil {
+			cmd.Printf("  Cooldown: scale-out %s, scale-in %s\n",
+				formatSeconds(policy.ScaleOutCooldown), formatSeconds(policy.ScaleInCooldown))
+		}
+
+		if policy.DisableScaleIn {
+			cmd.Println("  Scale-in disabled")
+		}
+
+		for _, alarm := range policy.Alarms {
+			state := alarm.State
+			if state == "" {
+				state = "UNKNOWN"
+			}
+
+			cmd.Printf("  Alarm %s: %s\n", alarm.Name, alarmStateStyle(state).Render(state))
+		}
+	}
+
+	return nil
+}
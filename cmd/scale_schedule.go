@@ -0,0 +1,261 @@
+// ABOUTME: Command-line interface for scheduled scaling of ECS services
+// ABOUTME: Manages Application Auto Scaling scheduled actions of the service
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+func newScaleScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage scheduled changes of the service's task count",
+	}
+
+	cmd.AddCommand(newScaleScheduleAddCommand())
+	cmd.AddCommand(newScaleScheduleListCommand())
+	cmd.AddCommand(newScaleScheduleRemoveCommand())
+
+	return cmd
+}
+
+func newScaleScheduleAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "add",
+		Short:                 "Add or replace a scheduled scaling action",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		PreRunE:               scaleScheduleAddPreRunE,
+		RunE:                  scaleScheduleAddHandler,
+	}
+
+	cmd.Flags().String("cron", "", "when to scale, as a cron expression (e.g., \"0 20 * * MON-FRI\") or at(...)/rate(...)")
+	cmd.Flags().Int32("min", 0, "minimum number of tasks from the scheduled time")
+	cmd.Flags().Int32("max", 0, "maximum number of tasks from the scheduled time")
+	cmd.Flags().String("name", "", "name of the scheduled action (default derived from the schedule)")
+	cmd.Flags().String("timezone", "", "IANA time zone of the schedule (default UTC)")
+
+	return cmd
+}
+
+func newScaleScheduleListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                   "list",
+		Short:                 "List the scheduled scaling actions of the service",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE:                  scaleScheduleListHandler,
+	}
+}
+
+func newScaleScheduleRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:                   "remove <name>",
+		Short:                 "Remove a scheduled scaling action",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE:                  scaleScheduleRemoveHandler,
+	}
+}
+
+// scheduledScaleOptionsFromFlags builds the scheduled action from the flags
+func scheduledScaleOptionsFromFlags(cmd *cobra.Command) (ecs.ScheduledScaleOptions, error) {
+	opts := ecs.ScheduledScaleOptions{}
+
+	opts.Schedule, _ = cmd.Flags().GetString("cron")
+	opts.Name, _ = cmd.Flags().GetString("name")
+	opts.Timezone, _ = cmd.Flags().GetString("timezone")
+
+	if opts.Schedule == "" {
+		return opts, errors.New("--cron is required")
+	}
+
+	if opts.Timezone != "" {
+		_, err := time.LoadLocation(opts.Timezone)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --timezone: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed("min") {
+		minCapacity, _ := cmd.Flags().GetInt32("min")
+		opts.MinCapacity = &minCapacity
+	}
+
+	if cmd.Flags().Changed("max") {
+		maxCapacity, _ := cmd.Flags().GetInt32("max")
+		opts.MaxCapacity = &maxCapacity
+	}
+
+	if opts.MinCapacity == nil && opts.MaxCapacity == nil {
+		return opts, errors.New("at least one of --min and --max is required")
+	}
+
+	if (opts.MinCapacity != nil && *opts.MinCapacity < 0) || (opts.MaxCapacity != nil && *opts.MaxCapacity < 0) {
+		return opts, errors.New("--min and --max cannot be negative")
+	}
+
+	return opts, nil
+}
+
+func scaleScheduleAddPreRunE(cmd *cobra.Command, args []string) error {
+	_, err := scheduledScaleOptionsFromFlags(cmd)
+
+	return err
+}
+
+// formatCapacity renders an optional capacity bound
+func formatCapacity(value *int32) string {
+	if value == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(int(*value))
+}
+
+func scaleScheduleAddHandler(cmd *cobra.Command, args []string) error {
+	opts, err := scheduledScaleOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	result, err := ecs.ScheduleScale(ctx, clients, cluster, service, opts)
+	if err != nil {
+		return fmt.Errorf("failed to schedule scaling: %w", err)
+	}
+
+	if result.RegisteredTarget != nil {
+		cmd.Printf("Registered service %s with auto scaling at %d-%d tasks\n",
+			boldStyle.Render(fmt.Sprintf("%s/%s", cluster, service)),
+			result.RegisteredTarget.MinCapacity, result.RegisteredTarget.MaxCapacity)
+	}
+
+	cmd.Printf("Scheduled action %s at %s sets min %s, max %s tasks\n",
+		boldStyle.Render(result.Action.Name), result.Action.Schedule,
+		formatCapacity(result.Action.MinCapacity), formatCapacity(result.Action.MaxCapacity))
+
+	return nil
+}
+
+func scaleScheduleListHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	actions, err := ecs.ListScheduledScaleActions(ctx, clients, cluster, service)
+	if err != nil {
+		return fmt.Errorf("failed to list scheduled actions: %w", err)
+	}
+
+	if len(actions) == 0 {
+		cmd.Println("No scheduled actions found.")
+
+		return nil
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	var rows [][]string
+
+	for _, action := range actions {
+		timezone := action.Timezone
+		if timezone == "" {
+			timezone = "UTC"
+		}
+
+		rows = append(rows, []string{
+			action.Name,
+			action.Schedule,
+			timezone,
+			formatCapacity(action.MinCapacity),
+			formatCapacity(action.MaxCapacity),
+			action.CreatedAt.Local().Format(time.DateTime)[:16],
+		})
+	}
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			// Right-align Min (col 3) and Max (col 4) columns
+			if col == 3 || col == 4 {
+				return cellStyle.Align(lipgloss.Right)
+			}
+
+			return cellStyle
+		}).
+		Headers("Name", "Schedule", "Timezone", "Min", "Max", "Created").
+		Rows(rows...)
+
+	cmd.Println(t)
+
+	return nil
+}
+
+func scaleScheduleRemoveHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	// Set up context that cancels on interrupt signal for proper Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	err = ecs.RemoveScheduledScaleAction(ctx, clients, cluster, service, args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Scheduled action %s has been removed\n", boldStyle.Render(args[0]))
+
+	return nil
+}
@@ -0,0 +1,250 @@
+// ABOUTME: Command-line interface for the status of an ECS service
+// ABOUTME: Shows deployments, tasks, target health and events in one view
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+const (
+	statusFormatText = "text"
+	statusFormatJSON = "json"
+)
+
+func newStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "status",
+		Short:                 "Show deployments, tasks, target health and events of the service",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		PreRunE:               statusPreRunE,
+		RunE:                  statusHandler,
+	}
+
+	cmd.Flags().String("format", statusFormatText, "output format (text or json)")
+	cmd.Flags().Int("events", 5, "number of recent service events to show")
+
+	return cmd
+}
+
+func statusPreRunE(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != statusFormatText && format != statusFormatJSON {
+		return fmt.Errorf("invalid format %q: must be %s or %s", format, statusFormatText, statusFormatJSON)
+	}
+
+	events, _ := cmd.Flags().GetInt("events")
+	if events < 0 {
+		return fmt.Errorf("invalid number of events %d", events)
+	}
+
+	return nil
+}
+
+func statusHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	events, _ := cmd.Flags().GetInt("events")
+
+	// Set up context that cancels on interrupt signal for cancellable status operations
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	status, err := ecs.GetServiceStatus(ctx, clients, cluster, service, events)
+	if err != nil {
+		return fmt.Errorf("failed to get service status: %w", err)
+	}
+
+	if format == statusFormatJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(status)
+		if err != nil {
+			return fmt.Errorf("failed to write status: %w", err)
+		}
+
+		return nil
+	}
+
+	displayServiceStatus(cmd, status)
+
+	return nil
+}
+
+// healthStyle colors a task or target health: green when healthy, red when
+// unhealthy, default otherwise
+func healthStyle(health string) lipgloss.Style {
+	switch strings.ToLower(health) {
+	case "healthy":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	case "unhealthy":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+// rolloutStyle colors a deployment rollout state
+func rolloutStyle(state string) lipgloss.Style {
+	switch state {
+	case "FAILED":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	case "IN_PROGRESS":
+		return warningStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+func statusTable(headers []string, rows [][]string, rightAligned ...int) *table.Table {
+	headerStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	return table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+
+			for _, aligned := range rightAligned {
+				if col == aligned {
+					return cellStyle.Align(lipgloss.Right)
+				}
+			}
+
+			return cellStyle
+		}).
+		Headers(headers...).
+		Rows(rows...)
+}
+
+func formatCircuitBreaker(breaker *ecs.CircuitBreakerStatus) string {
+	switch {
+	case breaker == nil || !breaker.Enabled:
+		return "disabled"
+	case breaker.Rollback:
+		return "enabled, rolls back failed deployments"
+	default:
+		return "enabled, without rollback"
+	}
+}
+
+func displayServiceStatus(cmd *cobra.Command, status *ecs.ServiceStatus) {
+	dateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+
+	cmd.Printf("Service %s/%s (region: %s)\n", status.Cluster, boldStyle.Render(status.Service), boldStyle.Render(status.Region))
+	cmd.Println()
+	cmd.Printf("Status:          %s\n", status.Status)
+	cmd.Printf("Task definition: %s\n", status.TaskDefinition)
+	cmd.Printf("Tasks:           %d desired, %d running, %d pending\n", status.DesiredCount, status.RunningCount, status.PendingCount)
+	cmd.Printf("Circuit breaker: %s\n", formatCircuitBreaker(status.CircuitBreaker))
+	cmd.Println()
+
+	var deploymentRows [][]string
+
+	for _, deployment := range status.Deployments {
+		rollout := rolloutStyle(deployment.RolloutState).Render(deployment.RolloutState)
+		if deployment.RolloutStateReason != "" {
+			rollout += "\n" + deployment.RolloutStateReason
+		}
+
+		deploymentRows = append(deploymentRows, []string{
+			deployment.Status,
+			deployment.TaskDefinition,
+			strconv.Itoa(int(deployment.DesiredCount)),
+			strconv.Itoa(int(deployment.RunningCount)),
+			strconv.Itoa(int(deployment.PendingCount)),
+			strconv.Itoa(int(deployment.FailedTasks)),
+			rollout,
+			dateStyle.Render(deployment.UpdatedAt.Local().Format(time.DateTime)[:16]),
+		})
+	}
+
+	cmd.Println(boldStyle.Render("Deployments"))
+	cmd.Println(statusTable([]string{"Status", "Task Definition", "Desired", "Running", "Pending", "Failed", "Rollout", "Updated"},
+		deploymentRows, 2, 3, 4, 5))
+	cmd.Println()
+
+	cmd.Println(boldStyle.Render("Tasks"))
+
+	if len(status.Tasks) == 0 {
+		cmd.Println("No running tasks found.")
+	} else {
+		var taskRows [][]string
+
+		for _, task := range status.Tasks {
+			taskRows = append(taskRows, []string{
+				task.ID,
+				task.TaskDefinition,
+				task.LastStatus,
+				healthStyle(task.HealthStatus).Render(task.HealthStatus),
+				task.AvailabilityZone,
+				task.RunningTime,
+			})
+		}
+
+		cmd.Println(statusTable([]string{"Task ID", "Task Definition", "Status", "Health", "Zone", "Running Time"}, taskRows, 5))
+	}
+
+	if len(status.Targets) > 0 {
+		var targetRows [][]string
+
+		for _, target := range status.Targets {
+			address := target.Target
+			if target.Port != 0 {
+				address += ":" + strconv.Itoa(int(target.Port))
+			}
+
+			targetRows = append(targetRows, []string{
+				target.TargetGroup,
+				address,
+				target.TaskID,
+				healthStyle(target.State).Render(target.State),
+				target.Description,
+			})
+		}
+
+		cmd.Println()
+		cmd.Println(boldStyle.Render("Load balancer targets"))
+		cmd.Println(statusTable([]string{"Target Group", "Target", "Task ID", "State", "Reason"}, targetRows))
+	}
+
+	if len(status.Events) > 0 {
+		cmd.Println()
+		cmd.Println(boldStyle.Render("Events"))
+
+		for _, event := range status.Events {
+			cmd.Printf("%s  %s\n", dateStyle.Render(event.CreatedAt.Local().Format(time.DateTime)), event.Message)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(newStatusCommand())
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+func newTaskDefCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "taskdef",
+		Short: "Work with the service's task definition",
+	}
+
+	cmd.AddCommand(newTaskDefExportCommand())
+
+	return cmd
+}
+
+func newTaskDefExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "export",
+		Short:                 "Export the service's task definition as a registrable document",
+		DisableFlagsInUseLine: true,
+		PreRunE:               taskDefExportPreRunE,
+		RunE:                  taskDefExportHandler,
+	}
+
+	cmd.Flags().String("revision", ecs.RevisionService, "revision to export: service (the one the service runs), latest or a number")
+	cmd.Flags().String("format", ecs.DocumentFormatJSON, "output format (json or yaml)")
+	cmd.Flags().StringP("out", "o", "", "write the document to a file instead of stdout")
+
+	_ = cmd.RegisterFlagCompletionFunc("revision", completeRevisionFlag)
+
+	return cmd
+}
+
+func taskDefExportPreRunE(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to get format flag: %w", err)
+	}
+
+	if format != ecs.DocumentFormatJSON && format != ecs.DocumentFormatYAML {
+		return fmt.Errorf("invalid format %q: must be %s or %s", format, ecs.DocumentFormatJSON, ecs.DocumentFormatYAML)
+	}
+
+	_, err = revisionFlag(cmd)
+
+	return err
+}
+
+func taskDefExportHandler(cmd *cobra.Command, args []string) error {
+	cluster, service, err := parseServiceFlag()
+	if err != nil {
+		return err
+	}
+
+	revision, _ := cmd.Flags().GetString("revision")
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+
+	// Set up context that cancels on interrupt signal for cancellable export operations
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	result, err := ecs.ExportTaskDefinition(ctx, clients, cluster, service, revision, format)
+	if err != nil {
+		return fmt.Errorf("failed to export task definition: %w", err)
+	}
+
+	if out == "" {
+		_, err = cmd.OutOrStdout().Write(result.Document)
+		if err != nil {
+			return fmt.Errorf("failed to write task definition: %w", err)
+		}
+
+		return nil
+	}
+
+	err = os.WriteFile(out, result.Document, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write task definition to %s: %w", out, err)
+	}
+
+	cmd.Printf("Task definition %s written to %s\n", result.TaskDefinitionArn, out)
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newTaskDefCommand())
+}
@@ -0,0 +1,634 @@
+// ABOUTME: Command-line interface for a live terminal dashboard of ECS clusters
+// ABOUTME: Refreshes services and tasks and binds keys to restart, scale and stop actions
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"runecs.io/v1/internal/ecs"
+)
+
+const (
+	// topEventCount is the number of service events shown for a service
+	topEventCount = 10
+	// topLogLines is the number of log lines kept for a service
+	topLogLines = 200
+	// topStopReason is recorded on tasks stopped from the dashboard
+	topStopReason = "Stopped from runecs top"
+)
+
+func newTopCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Live dashboard of the services and tasks in a cluster",
+		Long: `Live dashboard of the services and tasks in a cluster.
+
+Shows the clusters of --cluster, the cluster of --service, or all clusters in
+the region. Select a service with the arrow keys and press enter to see its
+deployments, tasks and events and to tail its logs. Press r to restart the
+service, s to scale it and, in the service view, x to stop the selected task.`,
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Annotations:           map[string]string{serviceOptionalAnnotation: "true"},
+		PreRunE:               topPreRunE,
+		RunE:                  topHandler,
+	}
+
+	cmd.Flags().String("cluster", "", "cluster to show (default the cluster of --service, or all clusters)")
+	cmd.Flags().Duration("interval", 5*time.Second, "refresh interval")
+
+	_ = cmd.RegisterFlagCompletionFunc("cluster", completeClusters)
+
+	return cmd
+}
+
+func topPreRunE(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval < time.Second {
+		return errors.New("--interval must be at least 1s")
+	}
+
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		return errors.New("top requires a terminal")
+	}
+
+	return nil
+}
+
+func topHandler(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	cluster, _ := cmd.Flags().GetString("cluster")
+
+	if cluster == "" && rootCmd.Flag("service").Value.String() != "" {
+		serviceCluster, _, err := parseServiceFlag()
+		if err != nil {
+			return err
+		}
+
+		cluster = serviceCluster
+	}
+
+	// The dashboard handles Ctrl+C itself; cancelling stops pending calls and
+	// the log tail when it exits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	profile := rootCmd.Flag("profile").Value.String()
+	clients, err := ecs.NewAWSClients(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize AWS clients: %w", err)
+	}
+
+	clusters := []string{cluster}
+	if cluster == "" {
+		clusters, err = ecs.ListClusterNames(ctx, clients)
+		if err != nil {
+			return fmt.Errorf("failed to list clusters: %w", err)
+		}
+	}
+
+	model := &topModel{
+		ctx:      ctx,
+		clients:  clients,
+		clusters: clusters,
+		interval: interval,
+	}
+
+	_, err = tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return fmt.Errorf("dashboard failed: %w", err)
+	}
+
+	model.stopTail()
+
+	return nil
+}
+
+type topView int
+
+const (
+	topViewServices topView = iota
+	topViewService
+)
+
+type topPrompt int
+
+const (
+	topPromptNone topPrompt = iota
+	topPromptRestart
+	topPromptStopTask
+	topPromptScale
+)
+
+// topModel is the state of the dashboard
+type topModel struct {
+	ctx      context.Context
+	clients  *ecs.AWSClients
+	clusters []string
+	interval time.Duration
+
+	view     topView
+	services []ecs.ServiceOverview
+	cursor   int
+	updated  time.Time
+
+	// fetching is set while the overview is fetched. Ticks skip the fetch
+	// meanwhile, and refetch asks for another one once it finishes.
+	fetching bool
+	refetch  bool
+
+	// selected is the service open in the service view
+	selected   ecs.ServiceRef
+	status     *ecs.ServiceStatus
+	taskCursor int
+
+	tail     *ecs.LiveTail
+	showLogs bool
+	logs     []string
+
+	// prompt asks to confirm an action on target, or for the scale value
+	prompt     topPrompt
+	target     ecs.ServiceRef
+	targetTask string
+	input      string
+
+	message string
+	err     error
+
+	width, height int
+}
+
+type (
+	topTickMsg     struct{}
+	topOverviewMsg struct {
+		services []ecs.ServiceOverview
+		err      error
+	}
+	topStatusMsg struct {
+		ref    ecs.ServiceRef
+		status *ecs.ServiceStatus
+		err    error
+	}
+	topActionMsg struct {
+		message string
+		err     error
+	}
+	topTailMsg struct {
+		ref  ecs.ServiceRef
+		tail *ecs.LiveTail
+		err  error
+	}
+	topLogMsg struct {
+		tail  *ecs.LiveTail
+		entry ecs.LogEntry
+	}
+	topLogNoticeMsg struct {
+		tail   *ecs.LiveTail
+		notice string
+	}
+	topTailClosedMsg struct{ tail *ecs.LiveTail }
+)
+
+func (m *topModel) Init() tea.Cmd {
+	return tea.Batch(m.fetchOverview(), m.tick())
+}
+
+func (m *topModel) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return topTickMsg{} })
+}
+
+func (m *topModel) fetchOverview() tea.Cmd {
+	m.fetching = true
+
+	return func() tea.Msg {
+		var services []ecs.ServiceOverview
+
+		for _, cluster := range m.clusters {
+			overview, err := ecs.GetClusterOverview(m.ctx, m.clients, cluster)
+			if err != nil {
+				return topOverviewMsg{err: err}
+			}
+
+			services = append(services, overview.Services...)
+		}
+
+		return topOverviewMsg{services: services}
+	}
+}
+
+func (m *topModel) fetchStatus(ref ecs.ServiceRef) tea.Cmd {
+	return func() tea.Msg {
+		status, err := ecs.GetServiceStatus(m.ctx, m.clients, ref.Cluster, ref.Service, topEventCount)
+
+		return topStatusMsg{ref: ref, status: status, err: err}
+	}
+}
+
+// currentService returns the service the actions apply to: the open service
+// in the service view, the one under the cursor otherwise
+func (m *topModel) currentService() (ecs.ServiceRef, bool) {
+	if m.view == topViewService {
+		return m.selected, true
+	}
+
+	if m.cursor >= len(m.services) {
+		return ecs.ServiceRef{}, false
+	}
+
+	service := m.services[m.cursor]
+
+	return ecs.ServiceRef{Cluster: service.Cluster, Service: service.Service}, true
+}
+
+func (m *topModel) selectedTask() (ecs.TaskStatus, bool) {
+	if m.status == nil || m.taskCursor >= len(m.status.Tasks) {
+		return ecs.TaskStatus{}, false
+	}
+
+	return m.status.Tasks[m.taskCursor], true
+}
+
+func (m *topModel) restart(ref ecs.ServiceRef) tea.Cmd {
+	return func() tea.Msg {
+		_, err := ecs.Restart(m.ctx, m.clients, ref.Cluster, ref.Service, ecs.RestartOptions{})
+		if err != nil {
+			return topActionMsg{err: fmt.Errorf("failed to restart %s: %w", ref, err)}
+		}
+
+		return topActionMsg{message: fmt.Sprintf("Service %s restarting with a new deployment", ref)}
+	}
+}
+
+func (m *topModel) scale(ref ecs.ServiceRef, value string) tea.Cmd {
+	return func() tea.Msg {
+		change, err := ecs.ParseScaleChange(value)
+		if err != nil {
+			return topActionMsg{err: err}
+		}
+
+		result, err := ecs.Scale(m.ctx, m.clients, ref.Cluster, ref.Service, change, ecs.ScaleOptions{})
+		if err != nil {
+			return topActionMsg{err: fmt.Errorf("failed to scale %s: %w", ref, err)}
+		}
+
+		message := fmt.Sprintf("Service %s scaled from %d to %d tasks", ref, result.PreviousDesiredCount, result.NewDesiredCount)
+		if len(result.Warnings) > 0 {
+			message += " (warning: " + result.Warnings[0] + ")"
+		}
+
+		return topActionMsg{message: message}
+	}
+}
+
+func (m *topModel) stopTask(ref ecs.ServiceRef, taskID string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := ecs.Restart(m.ctx, m.clients, ref.Cluster, ref.Service, ecs.RestartOptions{
+			Kill:       true,
+			TaskIDs:    []string{taskID},
+			StopReason: topStopReason,
+		})
+		if err == nil && len(result.StopFailures) > 0 {
+			err = result.StopFailures[0].Err
+		}
+
+		if err != nil {
+			return topActionMsg{err: fmt.Errorf("failed to stop task %s: %w", taskID, err)}
+		}
+
+		return topActionMsg{message: fmt.Sprintf("Stopped task %s", taskID)}
+	}
+}
+
+func (m *topModel) startTail(ref ecs.ServiceRef) tea.Cmd {
+	return func() tea.Msg {
+		tail, err := ecs.TailServiceLogs(m.ctx, m.clients, ref.Cluster, ref.Service, ecs.RevisionService)
+
+		return topTailMsg{ref: ref, tail: tail, err: err}
+	}
+}
+
+// waitForLog reads the next log event or notice of the tail
+func waitForLog(tail *ecs.LiveTail) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case entry, ok := <-tail.Logs:
+			if !ok {
+				return topTailClosedMsg{tail: tail}
+			}
+
+			return topLogMsg{tail: tail, entry: entry}
+		case notice, ok := <-tail.Notices:
+			if !ok {
+				return topTailClosedMsg{tail: tail}
+			}
+
+			return topLogNoticeMsg{tail: tail, notice: notice}
+		}
+	}
+}
+
+func (m *topModel) stopTail() {
+	if m.tail != nil {
+		m.tail.Close()
+		m.tail = nil
+	}
+
+	m.showLogs = false
+	m.logs = nil
+}
+
+func (m *topModel) appendLog(line string) {
+	m.logs = append(m.logs, line)
+	if len(m.logs) > topLogLines {
+		m.logs = m.logs[len(m.logs)-topLogLines:]
+	}
+}
+
+func (m *topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.prompt != topPromptNone {
+			return m, m.updatePrompt(msg)
+		}
+
+		return m, m.updateKeys(msg)
+
+	case topTickMsg:
+		// Listing every cluster can take longer than the interval on a
+		// large account; overlapping fetches would only pile up
+		cmds := []tea.Cmd{m.tick()}
+		if !m.fetching {
+			cmds = append(cmds, m.fetchOverview())
+		}
+
+		if m.view == topViewService {
+			cmds = append(cmds, m.fetchStatus(m.selected))
+		}
+
+		return m, tea.Batch(cmds...)
+
+	case topOverviewMsg:
+		m.fetching = false
+
+		var cmd tea.Cmd
+		if m.refetch {
+			m.refetch = false
+			cmd = m.fetchOverview()
+		}
+
+		if msg.err != nil {
+			m.err = msg.err
+
+			return m, cmd
+		}
+
+		m.services = msg.services
+		m.cursor = min(m.cursor, max(len(m.services)-1, 0))
+		m.updated = time.Now()
+		m.err = nil
+
+		return m, cmd
+
+	case topStatusMsg:
+		// Ignore responses for a service the user already left
+		if m.view != topViewService || msg.ref != m.selected {
+			return m, nil
+		}
+
+		if msg.err != nil {
+			m.err = msg.err
+
+			return m, nil
+		}
+
+		m.status = msg.status
+		m.taskCursor = min(m.taskCursor, max(len(m.status.Tasks)-1, 0))
+		m.err = nil
+
+		return m, nil
+
+	case topActionMsg:
+		m.message, m.err = msg.message, msg.err
+
+		// A fetch already running may predate the action, so follow it with
+		// another one
+		var cmds []tea.Cmd
+		if m.fetching {
+			m.refetch = true
+		} else {
+			cmds = append(cmds, m.fetchOverview())
+		}
+
+		if m.view == topViewService {
+			cmds = append(cmds, m.fetchStatus(m.selected))
+		}
+
+		return m, tea.Batch(cmds...)
+
+	case topTailMsg:
+		if msg.err != nil {
+			m.showLogs = false
+			m.err = fmt.Errorf("failed to tail logs: %w", msg.err)
+
+			return m, nil
+		}
+
+		if !m.showLogs || msg.ref != m.selected {
+			msg.tail.Close()
+
+			return m, nil
+		}
+
+		m.tail = msg.tail
+
+		return m, waitForLog(m.tail)
+
+	case topLogMsg:
+		if msg.tail != m.tail {
+			return m, nil
+		}
+
+		m.appendLog(formatTopLogEntry(msg.entry))
+
+		return m, waitForLog(m.tail)
+
+	case topLogNoticeMsg:
+		if msg.tail != m.tail {
+			return m, nil
+		}
+
+		m.appendLog(warningStyle.Render("Warning: " + msg.notice))
+
+		return m, waitForLog(m.tail)
+
+	case topTailClosedMsg:
+		if msg.tail == m.tail {
+			m.tail = nil
+			m.appendLog(warningStyle.Render("Log tail ended"))
+		}
+
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// updateKeys handles the keys outside of prompts
+func (m *topModel) updateKeys(msg tea.KeyMsg) tea.Cmd {
+	m.message = ""
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.stopTail()
+
+		return tea.Quit
+
+	case "up", "k":
+		if m.view == topViewService {
+			m.taskCursor = max(m.taskCursor-1, 0)
+		} else {
+			m.cursor = max(m.cursor-1, 0)
+		}
+
+	case "down", "j":
+		if m.view == topViewService {
+			if m.status != nil {
+				m.taskCursor = min(m.taskCursor+1, max(len(m.status.Tasks)-1, 0))
+			}
+		} else {
+			m.cursor = min(m.cursor+1, max(len(m.services)-1, 0))
+		}
+
+	case "enter":
+		ref, ok := m.currentService()
+		if m.view == topViewServices && ok {
+			m.view = topViewService
+			m.selected = ref
+			m.status = nil
+			m.taskCursor = 0
+
+			return m.fetchStatus(ref)
+		}
+
+	case "esc", "backspace":
+		if m.view == topViewService {
+			m.stopTail()
+			m.view = topViewServices
+			m.status = nil
+		}
+
+	case "r":
+		if ref, ok := m.currentService(); ok {
+			m.prompt = topPromptRestart
+			m.target = ref
+		}
+
+	case "s":
+		if ref, ok := m.currentService(); ok {
+			m.prompt = topPromptScale
+			m.target = ref
+			m.input = ""
+		}
+
+	case "x":
+		if task, ok := m.selectedTask(); ok && m.view == topViewService {
+			m.prompt = topPromptStopTask
+			m.target = m.selected
+			m.targetTask = task.ID
+		}
+
+	case "l":
+		if m.view != topViewService {
+			break
+		}
+
+		if m.showLogs {
+			m.stopTail()
+
+			break
+		}
+
+		m.showLogs = true
+
+		return m.startTail(m.selected)
+	}
+
+	return nil
+}
+
+// updatePrompt handles the keys of a confirmation or the scale input
+func (m *topModel) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	ref := m.target
+	prompt := m.prompt
+
+	if msg.String() == "ctrl+c" {
+		m.stopTail()
+
+		return tea.Quit
+	}
+
+	if msg.String() == "esc" {
+		m.prompt = topPromptNone
+
+		return nil
+	}
+
+	if prompt == topPromptScale {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.prompt = topPromptNone
+			if strings.TrimSpace(m.input) == "" {
+				return nil
+			}
+
+			m.message = fmt.Sprintf("Scaling %s...", ref)
+
+			return m.scale(ref, strings.TrimSpace(m.input))
+		case tea.KeyBackspace:
+			if len(m.input) > 0 {
+				m.input = m.input[:len(m.input)-1]
+			}
+		case tea.KeyRunes:
+			m.input += string(msg.Runes)
+		}
+
+		return nil
+	}
+
+	m.prompt = topPromptNone
+
+	if msg.String() != "y" {
+		return nil
+	}
+
+	switch prompt {
+	case topPromptRestart:
+		m.message = fmt.Sprintf("Restarting %s...", ref)
+
+		return m.restart(ref)
+	case topPromptStopTask:
+		m.message = fmt.Sprintf("Stopping task %s...", m.targetTask)
+
+		return m.stopTask(ref, m.targetTask)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newTopCommand())
+}
@@ -0,0 +1,243 @@
+// ABOUTME: Rendering of the live terminal dashboard of ECS clusters
+// ABOUTME: Draws the service list, the service view with logs and the key help
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"runecs.io/v1/internal/ecs"
+)
+
+var (
+	topSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	topHelpStyle     = lipgloss.NewStyle().Faint(true)
+	topErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+)
+
+// topTable renders a table whose selected row is highlighted; selected is -1
+// when no row is selected
+func topTable(headers []string, rows [][]string, selected int, rightAligned ...int) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	return table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+
+			style := cellStyle
+			for _, aligned := range rightAligned {
+				if col == aligned {
+					style = style.Align(lipgloss.Right)
+				}
+			}
+
+			if row == selected {
+				style = style.Inherit(topSelectedStyle)
+			}
+
+			return style
+		}).
+		Headers(headers...).
+		Rows(rows...).
+		String()
+}
+
+func formatTopLogEntry(entry ecs.LogEntry) string {
+	timestamp := time.UnixMilli(entry.Timestamp).Local().Format(time.TimeOnly)
+
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(timestamp) + " " + strings.TrimRight(entry.Message, "\n")
+}
+
+func (m *topModel) View() string {
+	var b strings.Builder
+
+	title := "runecs top"
+	if !m.updated.IsZero() {
+		title += fmt.Sprintf(" (region: %s, updated %s)", m.clients.Region, m.updated.Local().Format(time.TimeOnly))
+	}
+
+	b.WriteString(boldStyle.Render(title))
+	b.WriteString("\n\n")
+
+	if m.view == topViewService {
+		b.WriteString(m.serviceView())
+	} else {
+		b.WriteString(m.servicesView())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.footer())
+
+	return b.String()
+}
+
+func (m *topModel) servicesView() string {
+	if m.updated.IsZero() && m.err == nil {
+		return "Loading services...\n"
+	}
+
+	if len(m.services) == 0 {
+		return "No services found.\n"
+	}
+
+	// Keep the cursor visible when there are more services than lines
+	start, end := 0, len(m.services)
+	if visible := m.serviceLines(); visible < len(m.services) {
+		start = max(m.cursor-visible+1, 0)
+		end = min(start+visible, len(m.services))
+	}
+
+	rows := make([][]string, 0, end-start)
+
+	for _, service := range m.services[start:end] {
+		rows = append(rows, []string{
+			fmt.Sprintf("%s/%s", service.Cluster, service.Service),
+			service.TaskDefinition,
+			strconv.Itoa(int(service.DesiredCount)),
+			strconv.Itoa(int(service.RunningCount)),
+			strconv.Itoa(int(service.PendingCount)),
+			service.RolloutState,
+		})
+	}
+
+	view := topTable([]string{"Service", "Task Definition", "Desired", "Running", "Pending", "Rollout"}, rows, m.cursor-start, 2, 3, 4) + "\n"
+
+	if start > 0 || end < len(m.services) {
+		view += topHelpStyle.Render(fmt.Sprintf("%d-%d of %d services", start+1, end, len(m.services))) + "\n"
+	}
+
+	return view
+}
+
+// serviceLines returns how many services fit between the title and the
+// footer; all of them until the terminal size is known
+func (m *topModel) serviceLines() int {
+	if m.height == 0 {
+		return len(m.services)
+	}
+
+	// Title, table borders and header, position line and footer
+	return max(m.height-11, 3)
+}
+
+func (m *topModel) serviceView() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Service %s/%s\n", m.selected.Cluster, boldStyle.Render(m.selected.Service))
+
+	if m.status == nil {
+		b.WriteString("Loading service...\n")
+
+		return b.String()
+	}
+
+	status := m.status
+
+	fmt.Fprintf(&b, "Task definition %s, %d desired, %d running, %d pending\n",
+		status.TaskDefinition, status.DesiredCount, status.RunningCount, status.PendingCount)
+
+	for _, deployment := range status.Deployments {
+		fmt.Fprintf(&b, "Deployment %s %s: %d/%d running %s\n", strings.ToLower(deployment.Status), deployment.TaskDefinition,
+			deployment.RunningCount, deployment.DesiredCount, rolloutStyle(deployment.RolloutState).Render(deployment.RolloutState))
+	}
+
+	b.WriteString("\n")
+
+	if len(status.Tasks) == 0 {
+		b.WriteString("No running tasks found.\n")
+	} else {
+		rows := make([][]string, 0, len(status.Tasks))
+
+		for _, task := range status.Tasks {
+			rows = append(rows, []string{
+				task.ID,
+				task.TaskDefinition,
+				task.LastStatus,
+				task.HealthStatus,
+				task.AvailabilityZone,
+				task.RunningTime,
+			})
+		}
+
+		b.WriteString(topTable([]string{"Task ID", "Task Definition", "Status", "Health", "Zone", "Running Time"}, rows, m.taskCursor, 5))
+		b.WriteString("\n")
+	}
+
+	if m.showLogs {
+		b.WriteString("\n")
+		b.WriteString(boldStyle.Render("Logs"))
+		b.WriteString("\n")
+
+		if len(m.logs) == 0 {
+			b.WriteString("Waiting for logs...\n")
+		}
+
+		for _, line := range m.logs[max(len(m.logs)-m.logLines(), 0):] {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+
+		return b.String()
+	}
+
+	if len(status.Events) > 0 {
+		b.WriteString("\n")
+		b.WriteString(boldStyle.Render("Events"))
+		b.WriteString("\n")
+
+		for _, event := range status.Events {
+			fmt.Fprintf(&b, "%s  %s\n", event.CreatedAt.Local().Format(time.DateTime), event.Message)
+		}
+	}
+
+	return b.String()
+}
+
+// logLines returns how many log lines fit below the tasks
+func (m *topModel) logLines() int {
+	used := 12
+	if m.status != nil {
+		used += len(m.status.Deployments) + 2*len(m.status.Tasks)
+	}
+
+	return max(m.height-used, 5)
+}
+
+func (m *topModel) footer() string {
+	var b strings.Builder
+
+	switch m.prompt {
+	case topPromptRestart:
+		fmt.Fprintf(&b, "Restart %s with a new deployment? (y/n) ", m.target)
+	case topPromptStopTask:
+		fmt.Fprintf(&b, "Stop task %s? (y/n) ", m.targetTask)
+	case topPromptScale:
+		fmt.Fprintf(&b, "Scale %s to (5, +2, -1, x2): %s", m.target, m.input)
+	default:
+		if m.err != nil {
+			b.WriteString(topErrorStyle.Render("Error: " + m.err.Error()))
+			b.WriteString("\n")
+		} else if m.message != "" {
+			b.WriteString(m.message)
+			b.WriteString("\n")
+		}
+
+		help := "↑/↓ select • enter open • r restart • s scale • q quit"
+		if m.view == topViewService {
+			help = "↑/↓ select task • x stop task • l logs • r restart • s scale • esc back • q quit"
+		}
+
+		b.WriteString(topHelpStyle.Render(help))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,472 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aastypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// scalableResourceID returns the Application Auto Scaling resource ID of an
+// ECS service, e.g. "service/my-cluster/my-service".
+func scalableResourceID(cluster, service string) string {
+	return fmt.Sprintf("service/%s/%s", cluster, service)
+}
+
+// getScalableTarget returns the scalable target registered for the desired
+// count of the service, or nil when auto scaling does not manage it.
+func getScalableTarget(ctx context.Context, cluster, service string, client *applicationautoscaling.Client) (*aastypes.ScalableTarget, error) {
+	response, err := client.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace:  aastypes.ServiceNamespaceEcs,
+		ResourceIds:       []string{scalableResourceID(cluster, service)},
+		ScalableDimension: aastypes.ScalableDimensionECSServiceDesiredCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scalable targets: %w", err)
+	}
+
+	if len(response.ScalableTargets) == 0 {
+		return nil, nil
+	}
+
+	return &response.ScalableTargets[0], nil
+}
+
+// scalableTargetBounds returns the capacity range of a scalable target
+func scalableTargetBounds(target *aastypes.ScalableTarget) *AutoScalingBounds {
+	return &AutoScalingBounds{
+		MinCapacity: aws.ToInt32(target.MinCapacity),
+		MaxCapacity: aws.ToInt32(target.MaxCapacity),
+	}
+}
+
+// updateScalableTargetBounds registers new min and max capacity for the
+// service's existing scalable target. Other settings such as the suspended
+// state are left as they are.
+func updateScalableTargetBounds(ctx context.Context, cluster, service string, bounds AutoScalingBounds, client *applicationautoscaling.Client) error {
+	_, err := client.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  aastypes.ServiceNamespaceEcs,
+		ResourceId:        aws.String(scalableResourceID(cluster, service)),
+		ScalableDimension: aastypes.ScalableDimensionECSServiceDesiredCount,
+		MinCapacity:       &bounds.MinCapacity,
+		MaxCapacity:       &bounds.MaxCapacity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update auto scaling bounds: %w", err)
+	}
+
+	return nil
+}
+
+// awsDayOfWeek converts one numeric day of the week from standard cron, where
+// 0 and 7 are Sunday, to AWS cron, where Sunday is 1 and Saturday 7. Day
+// names are the same in both and are returned unchanged.
+func awsDayOfWeek(day string) (string, error) {
+	number, err := strconv.Atoi(day)
+	if err != nil {
+		return day, nil
+	}
+
+	if number < 0 || number > 7 {
+		return "", fmt.Errorf("invalid day of week %q: expected 0-7 or SUN-SAT", day)
+	}
+
+	return strconv.Itoa(number%7 + 1), nil
+}
+
+// awsDayOfWeekField converts a standard cron day-of-week field, such as
+// "1-5", "0,6", "5L" or "1#2", to AWS cron numbering.
+func awsDayOfWeekField(field string) (string, error) {
+	items := strings.Split(field, ",")
+
+	for i, item := range items {
+		item, step, hasStep := strings.Cut(item, "/")
+
+		var suffix string
+		if day, nth, ok := strings.Cut(item, "#"); ok {
+			item, suffix = day, "#"+nth
+		} else if day, ok := strings.CutSuffix(item, "L"); ok && day != "" {
+			item, suffix = day, "L"
+		}
+
+		if item == "*" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(item, "-")
+
+		start, err := awsDayOfWeek(start)
+		if err != nil {
+			return "", err
+		}
+
+		converted := start
+
+		if isRange {
+			end, err = awsDayOfWeek(end)
+			if err != nil {
+				return "", err
+			}
+
+			converted = start + "-" + end
+
+			// A range ending on Sunday (7 in standard cron) now ends on 1,
+			// so split it into the days up to Saturday and Sunday itself
+			if end == "1" && start != "1" {
+				if hasStep {
+					return "", fmt.Errorf("invalid day of week %q: use day names for stepped ranges ending on Sunday", field)
+				}
+
+				converted = start + "-7,1"
+			} else if end == "1" {
+				converted = "1-7"
+			}
+		}
+
+		if hasStep {
+			converted += "/" + step
+		}
+
+		items[i] = converted + suffix
+	}
+
+	return strings.Join(items, ","), nil
+}
+
+// scheduleExpression converts a five-field cron expression into the
+// six-field cron(...) form used by Application Auto Scaling, where one of the
+// day fields must be "?" and a year field is required. Numeric days of the
+// week are renumbered, because AWS counts them 1-7 from Sunday instead of
+// 0-6. Expressions already in at(...), rate(...) or cron(...) form are
+// returned unchanged.
+func scheduleExpression(schedule string) (string, error) {
+	schedule = strings.TrimSpace(schedule)
+
+	for _, prefix := range []string{"at(", "rate(", "cron("} {
+		if strings.HasPrefix(schedule, prefix) {
+			return schedule, nil
+		}
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return "", fmt.Errorf("invalid schedule %q: expected five cron fields (minute hour day-of-month month day-of-week)", schedule)
+	}
+
+	dayOfMonth, dayOfWeek := fields[2], fields[4]
+
+	switch {
+	case dayOfWeek == "*" || dayOfWeek == "?":
+		dayOfWeek = "?"
+	case dayOfMonth == "*" || dayOfMonth == "?":
+		dayOfMonth = "?"
+
+		var err error
+
+		dayOfWeek, err = awsDayOfWeekField(dayOfWeek)
+		if err != nil {
+			return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+	default:
+		return "", fmt.Errorf("invalid schedule %q: day-of-month and day-of-week cannot both be set", schedule)
+	}
+
+	return fmt.Sprintf("cron(%s %s %s %s %s *)", fields[0], fields[1], dayOfMonth, fields[3], dayOfWeek), nil
+}
+
+// scheduledActionNamePattern matches the characters replaced in names derived
+// from a schedule
+var scheduledActionNamePattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// scheduledActionName derives an action name such as
+// "runecs-0-20-x-x-MON-FRI" from a schedule; wildcards become "x".
+func scheduledActionName(schedule string) string {
+	name := strings.ReplaceAll(schedule, "*", "x")
+	name = scheduledActionNamePattern.ReplaceAllString(name, "-")
+
+	return "runecs-" + strings.Trim(name, "-")
+}
+
+func scheduledScaleAction(action aastypes.ScheduledAction) ScheduledScaleAction {
+	result := ScheduledScaleAction{
+		Name:     deref(action.ScheduledActionName),
+		Schedule: deref(action.Schedule),
+		Timezone: deref(action.Timezone),
+	}
+
+	if action.ScalableTargetAction != nil {
+		result.MinCapacity = action.ScalableTargetAction.MinCapacity
+		result.MaxCapacity = action.ScalableTargetAction.MaxCapacity
+	}
+
+	if action.CreationTime != nil {
+		result.CreatedAt = *action.CreationTime
+	}
+
+	return result
+}
+
+// ScheduleScale adds or replaces a scheduled action that sets the auto
+// scaling bounds of the service. A service that auto scaling does not manage
+// yet is registered with its current desired count as both bounds, so
+// nothing changes until the first action runs.
+func ScheduleScale(ctx context.Context, clients *AWSClients, cluster, service string, opts ScheduledScaleOptions) (*ScheduleScaleResult, error) {
+	if opts.MinCapacity == nil && opts.MaxCapacity == nil {
+		return nil, errors.New("a scheduled action needs a minimum or maximum capacity")
+	}
+
+	if opts.MinCapacity != nil && opts.MaxCapacity != nil && *opts.MinCapacity > *opts.MaxCapacity {
+		return nil, fmt.Errorf("minimum capacity %d is greater than maximum capacity %d", *opts.MinCapacity, *opts.MaxCapacity)
+	}
+
+	schedule, err := scheduleExpression(opts.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = scheduledActionName(opts.Schedule)
+	}
+
+	target, err := getScalableTarget(ctx, cluster, service, clients.ApplicationAutoScaling)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScheduleScaleResult{}
+
+	if target == nil {
+		desiredCount, err := serviceDesiredCount(ctx, cluster, service, clients.ECS)
+		if err != nil {
+			return nil, err
+		}
+
+		bounds := AutoScalingBounds{MinCapacity: desiredCount, MaxCapacity: desiredCount}
+
+		err = updateScalableTargetBounds(ctx, cluster, service, bounds, clients.ApplicationAutoScaling)
+		if err != nil {
+			return nil, err
+		}
+
+		result.RegisteredTarget = &bounds
+	}
+
+	input := &applicationautoscaling.PutScheduledActionInput{
+		ServiceNamespace:    aastypes.ServiceNamespaceEcs,
+		ResourceId:          aws.String(scalableResourceID(cluster, service)),
+		ScalableDimension:   aastypes.ScalableDimensionECSServiceDesiredCount,
+		ScheduledActionName: &name,
+		Schedule:            &schedule,
+		ScalableTargetAction: &aastypes.ScalableTargetAction{
+			MinCapacity: opts.MinCapacity,
+			MaxCapacity: opts.MaxCapacity,
+		},
+	}
+
+	if opts.Timezone != "" {
+		input.Timezone = &opts.Timezone
+	}
+
+	_, err = clients.ApplicationAutoScaling.PutScheduledAction(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put scheduled action: %w", err)
+	}
+
+	result.Action = ScheduledScaleAction{
+		Name:        name,
+		Schedule:    schedule,
+		Timezone:    opts.Timezone,
+		MinCapacity: opts.MinCapacity,
+		MaxCapacity: opts.MaxCapacity,
+	}
+
+	return result, nil
+}
+
+// ListScheduledScaleActions returns the scheduled actions of the service
+func ListScheduledScaleActions(ctx context.Context, clients *AWSClients, cluster, service string) ([]ScheduledScaleAction, error) {
+	input := &applicationautoscaling.DescribeScheduledActionsInput{
+		ServiceNamespace:  aastypes.ServiceNamespaceEcs,
+		ResourceId:        aws.String(scalableResourceID(cluster, service)),
+		ScalableDimension: aastypes.ScalableDimensionECSServiceDesiredCount,
+	}
+
+	var actions []ScheduledScaleAction
+
+	for {
+		response, err := clients.ApplicationAutoScaling.DescribeScheduledActions(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe scheduled actions: %w", err)
+		}
+
+		for _, action := range response.ScheduledActions {
+			actions = append(actions, scheduledScaleAction(action))
+		}
+
+		if response.NextToken == nil {
+			break
+		}
+
+		input.NextToken = response.NextToken
+	}
+
+	return actions, nil
+}
+
+// RemoveScheduledScaleAction deletes a scheduled action of the service
+func RemoveScheduledScaleAction(ctx context.Context, clients *AWSClients, cluster, service, name string) error {
+	_, err := clients.ApplicationAutoScaling.DeleteScheduledAction(ctx, &applicationautoscaling.DeleteScheduledActionInput{
+		ServiceNamespace:    aastypes.ServiceNamespaceEcs,
+		ResourceId:          aws.String(scalableResourceID(cluster, service)),
+		ScalableDimension:   aastypes.ScalableDimensionECSServiceDesiredCount,
+		ScheduledActionName: &name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete scheduled action %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// scalingPolicy converts an Application Auto Scaling policy
+func scalingPolicy(policy aastypes.ScalingPolicy) ScalingPolicy {
+	result := ScalingPolicy{
+		Name: deref(policy.PolicyName),
+		Type: string(policy.PolicyType),
+	}
+
+	if config := policy.TargetTrackingScalingPolicyConfiguration; config != nil {
+		result.TargetValue = config.TargetValue
+		result.ScaleInCooldown = config.ScaleInCooldown
+		result.ScaleOutCooldown = config.ScaleOutCooldown
+		result.DisableScaleIn = aws.ToBool(config.DisableScaleIn)
+
+		switch {
+		case config.PredefinedMetricSpecification != nil:
+			result.Metric = string(config.PredefinedMetricSpecification.PredefinedMetricType)
+		case config.CustomizedMetricSpecification != nil:
+			result.Metric = deref(config.CustomizedMetricSpecification.MetricName)
+		}
+	}
+
+	if config := policy.StepScalingPolicyConfiguration; config != nil {
+		result.ScaleOutCooldown = config.Cooldown
+	}
+
+	for _, alarm := range policy.Alarms {
+		result.Alarms = append(result.Alarms, ScalingAlarm{Name: deref(alarm.AlarmName)})
+	}
+
+	return result
+}
+
+// fillAlarmStates looks up the current state of the policies' alarms
+func fillAlarmStates(ctx context.Context, policies []ScalingPolicy, client *cloudwatch.Client) error {
+	var names []string
+
+	for _, policy := range policies {
+		for _, alarm := range policy.Alarms {
+			names = append(names, alarm.Name)
+		}
+	}
+
+	states := map[string]cwtypes.MetricAlarm{}
+
+	// DescribeAlarms accepts at most 100 alarm names per call
+	for batch := range slices.Chunk(names, 100) {
+		response, err := client.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+			AlarmNames: batch,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe alarms: %w", err)
+		}
+
+		for _, alarm := range response.MetricAlarms {
+			states[deref(alarm.AlarmName)] = alarm
+		}
+	}
+
+	for i := range policies {
+		for j := range policies[i].Alarms {
+			alarm, ok := states[policies[i].Alarms[j].Name]
+			if !ok {
+				continue
+			}
+
+			policies[i].Alarms[j].State = string(alarm.StateValue)
+			policies[i].Alarms[j].Reason = deref(alarm.StateReason)
+		}
+	}
+
+	return nil
+}
+
+// DescribeScalingPolicies returns the auto scaling bounds and policies of the
+// service together with the current state of their CloudWatch alarms.
+func DescribeScalingPolicies(ctx context.Context, clients *AWSClients, cluster, service string) (*ScalingPoliciesResult, error) {
+	target, err := getScalableTarget(ctx, cluster, service, clients.ApplicationAutoScaling)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScalingPoliciesResult{}
+
+	if target == nil {
+		return result, nil
+	}
+
+	result.Bounds = scalableTargetBounds(target)
+
+	input := &applicationautoscaling.DescribeScalingPoliciesInput{
+		ServiceNamespace:  aastypes.ServiceNamespaceEcs,
+		ResourceId:        aws.String(scalableResourceID(cluster, service)),
+		ScalableDimension: aastypes.ScalableDimensionECSServiceDesiredCount,
+	}
+
+	for {
+		response, err := clients.ApplicationAutoScaling.DescribeScalingPolicies(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+		}
+
+		for _, policy := range response.ScalingPolicies {
+			result.Policies = append(result.Policies, scalingPolicy(policy))
+		}
+
+		if response.NextToken == nil {
+			break
+		}
+
+		input.NextToken = response.NextToken
+	}
+
+	err = fillAlarmStates(ctx, result.Policies, clients.CloudWatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// serviceListCacheTTL is how long cached lists of clusters and services
+	// are used before they are listed again
+	serviceListCacheTTL = 10 * time.Minute
+	// revisionListCacheTTL is how long cached revision numbers are used
+	revisionListCacheTTL = time.Minute
+	// taskListCacheTTL is how long cached task IDs are used
+	taskListCacheTTL = 30 * time.Second
+	// accountCacheTTL is how long the account behind a profile is remembered
+	accountCacheTTL = 24 * time.Hour
+)
+
+var (
+	// diskCacheDisabled makes lookups ignore the on-disk cache and refresh it
+	diskCacheDisabled bool
+
+	// memo holds immutable AWS objects, such as task definition revisions,
+	// for the life of the process
+	memo sync.Map
+)
+
+// DisableDiskCache makes every cached lookup call AWS and store the fresh
+// result, as if the cache were empty.
+func DisableDiskCache() {
+	diskCacheDisabled = true
+}
+
+// cacheEntry is the content of a cache file
+type cacheEntry struct {
+	SavedAt time.Time       `json:"savedAt"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// sanitizeCacheName makes name usable as a file name
+var sanitizeCacheName = strings.NewReplacer("/", "_", `\`, "_", ":", "_")
+
+// credentialsName names the credentials the clients were created with: the
+// access key when they come from the environment, and the profile otherwise
+func credentialsName(clients *AWSClients) string {
+	if clients.Profile == "" {
+		if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+			return "env-" + accessKey
+		}
+	}
+
+	profile := clients.Profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+
+	if profile == "" {
+		profile = "default"
+	}
+
+	return "profile-" + profile
+}
+
+// cacheAccount returns the account ID behind the clients' credentials. The
+// mapping is remembered on disk, because every shell completion runs in a new
+// process and would otherwise call STS on each Tab press, and fail with
+// expired credentials even when the cached lists are fresh.
+func cacheAccount(ctx context.Context, clients *AWSClients, dir string) (string, error) {
+	path := filepath.Join(dir, "accounts", sanitizeCacheName.Replace(credentialsName(clients))+".json")
+
+	var account string
+	if !diskCacheDisabled && readCache(path, accountCacheTTL, &account) && account != "" {
+		return account, nil
+	}
+
+	identity, err := callerIdentity(ctx, clients)
+	if err != nil {
+		return "", err
+	}
+
+	account = deref(identity.Account)
+	writeCache(path, account)
+
+	return account, nil
+}
+
+// cachePath returns the file caching name for the account and region of the
+// clients, in the user's cache directory. The account is looked up rather
+// than taken from --profile, because AWS_PROFILE, environment credentials or
+// SSO can point the same profile name at another account.
+func cachePath(ctx context.Context, clients *AWSClients, name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "runecs")
+
+	account, err := cacheAccount(ctx, clients, dir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, sanitizeCacheName.Replace(account+"-"+clients.Region), sanitizeCacheName.Replace(name)+".json"), nil
+}
+
+// readCache decodes the cached value into v when it is younger than ttl
+func readCache(path string, ttl time.Duration, v any) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var entry cacheEntry
+
+	err = json.Unmarshal(data, &entry)
+	if err != nil || time.Since(entry.SavedAt) > ttl {
+		return false
+	}
+
+	return json.Unmarshal(entry.Value, v) == nil
+}
+
+// writeCache stores v; failures only cost a slower next lookup, so they are
+// ignored
+func writeCache(path string, v any) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{SavedAt: time.Now().UTC(), Value: value})
+	if err != nil {
+		return
+	}
+
+	if os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+
+	tmp := path + ".tmp"
+	if os.WriteFile(tmp, data, 0o644) != nil {
+		return
+	}
+
+	_ = os.Rename(tmp, path)
+}
+
+// cached returns the value cached under name when it is younger than ttl,
+// and otherwise fetches and caches it.
+func cached[T any](ctx context.Context, clients *AWSClients, name string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	path, pathErr := cachePath(ctx, clients, name)
+
+	var value T
+	if pathErr == nil && !diskCacheDisabled && readCache(path, ttl, &value) {
+		return value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+
+	if pathErr == nil {
+		writeCache(path, value)
+	}
+
+	return value, nil
+}
+
+// memoized returns the value stored under key, fetching it on first use.
+// Only use it for objects that never change once created; the key must
+// identify the object uniquely, such as its ARN.
+func memoized[T any](key string, fetch func() (T, error)) (T, error) {
+	if value, ok := memo.Load(key); ok {
+		return value.(T), nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+
+	memo.Store(key, value)
+
+	return value, nil
+}
@@ -16,12 +16,20 @@ package ecs
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
@@ -46,9 +54,83 @@ func NewAWSClients(ctx context.Context, profile string) (*AWSClients, error) {
 	}
 
 	return &AWSClients{
-		ECS:            ecs.NewFromConfig(cfg),
-		CloudWatchLogs: cloudwatchlogs.NewFromConfig(cfg),
-		STS:            sts.NewFromConfig(cfg),
-		Region:         cfg.Region,
+		ECS:                    ecs.NewFromConfig(cfg),
+		CloudWatchLogs:         cloudwatchlogs.NewFromConfig(cfg),
+		CloudWatch:             cloudwatch.NewFromConfig(cfg),
+		STS:                    sts.NewFromConfig(cfg),
+		ApplicationAutoScaling: applicationautoscaling.NewFromConfig(cfg),
+		ELB:                    elasticloadbalancingv2.NewFromConfig(cfg),
+		EventBridge:            eventbridge.NewFromConfig(cfg),
+		Scheduler:              scheduler.NewFromConfig(cfg),
+		Region:                 cfg.Region,
+		Profile:                profile,
 	}, nil
 }
+
+// callerIdentity returns the identity behind the clients' credentials, which
+// does not change while the process runs
+func callerIdentity(ctx context.Context, clients *AWSClients) (*sts.GetCallerIdentityOutput, error) {
+	return memoized("caller-identity/"+clients.Profile+"/"+clients.Region, func() (*sts.GetCallerIdentityOutput, error) {
+		identity, err := clients.STS.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get caller identity: %w", err)
+		}
+
+		return identity, nil
+	})
+}
+
+// ListProfiles returns the profiles defined in the shared AWS config and
+// credentials files, honouring AWS_CONFIG_FILE and
+// AWS_SHARED_CREDENTIALS_FILE.
+func ListProfiles() []string {
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = config.DefaultSharedConfigFilename()
+	}
+
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = config.DefaultSharedCredentialsFilename()
+	}
+
+	var profiles []string
+
+	// The config file names profiles [profile name], except for [default];
+	// the credentials file names them [name]
+	for _, file := range []struct {
+		path   string
+		prefix string
+	}{{configFile, "profile "}, {credentialsFile, ""}} {
+		data, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			section, ok := strings.CutPrefix(strings.TrimSpace(line), "[")
+			if !ok {
+				continue
+			}
+
+			section, ok = strings.CutSuffix(section, "]")
+			if !ok {
+				continue
+			}
+
+			section = strings.TrimSpace(section)
+
+			if name, ok := strings.CutPrefix(section, file.prefix); ok || section == "default" {
+				if !ok {
+					name = section
+				}
+
+				profiles = append(profiles, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	slices.Sort(profiles)
+
+	return slices.Compact(profiles)
+}
@@ -17,54 +17,158 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/jinzhu/copier"
 	"runecs.io/v1/internal/utils"
 )
 
-func cloneTaskDef(ctx context.Context, cluster, service, dockerImageTag string, svc *ecs.Client) (string, error) {
-	// Get the last task definition ARN.
-	// Load the latest task definition.
-	latestDef, err := latestTaskDefinitionArn(ctx, cluster, service, svc)
+// preparedTaskDef is a task definition revision ready to be registered,
+// together with the revision it was cloned from.
+type preparedTaskDef struct {
+	Input    *ecs.RegisterTaskDefinitionInput
+	Previous *types.TaskDefinition
+	Image    ImageReference
+}
+
+// setEnvironment sets or replaces the environment variable name
+func setEnvironment(env []types.KeyValuePair, name, value string) []types.KeyValuePair {
+	for i := range env {
+		if deref(env[i].Name) == name {
+			env[i].Value = aws.String(value)
+
+			return env
+		}
+	}
+
+	return append(env, types.KeyValuePair{Name: aws.String(name), Value: aws.String(value)})
+}
+
+// setSecret sets or replaces the secret name
+func setSecret(secrets []types.Secret, name, valueFrom string) []types.Secret {
+	for i := range secrets {
+		if deref(secrets[i].Name) == name {
+			secrets[i].ValueFrom = aws.String(valueFrom)
+
+			return secrets
+		}
+	}
+
+	return append(secrets, types.Secret{Name: aws.String(name), ValueFrom: aws.String(valueFrom)})
+}
+
+func removeEnvironment(env []types.KeyValuePair, name string) []types.KeyValuePair {
+	return slices.DeleteFunc(env, func(pair types.KeyValuePair) bool {
+		return deref(pair.Name) == name
+	})
+}
+
+func removeSecret(secrets []types.Secret, name string) []types.Secret {
+	return slices.DeleteFunc(secrets, func(secret types.Secret) bool {
+		return deref(secret.Name) == name
+	})
+}
+
+// applyDeployOptions applies the requested changes to the container and task
+// definition. A variable is either plain or a secret, so setting one form
+// removes the other.
+func applyDeployOptions(newDef *ecs.RegisterTaskDefinitionInput, opts DeployOptions) {
+	container := &newDef.ContainerDefinitions[0]
+
+	for _, name := range utils.SortedKeys(opts.SetEnv) {
+		container.Secrets = removeSecret(container.Secrets, name)
+		container.Environment = setEnvironment(container.Environment, name, opts.SetEnv[name])
+	}
+
+	for _, name := range utils.SortedKeys(opts.Secrets) {
+		container.Environment = removeEnvironment(container.Environment, name)
+		container.Secrets = setSecret(container.Secrets, name, opts.Secrets[name])
+	}
+
+	for _, name := range opts.UnsetEnv {
+		container.Environment = removeEnvironment(container.Environment, name)
+		container.Secrets = removeSecret(container.Secrets, name)
+	}
+
+	if opts.Cpu != "" {
+		newDef.Cpu = aws.String(opts.Cpu)
+	}
+
+	if opts.Memory != "" {
+		newDef.Memory = aws.String(opts.Memory)
+	}
+
+	if len(opts.Command) > 0 {
+		container.Command = opts.Command
+	}
+}
+
+// prepareTaskDef clones the task definition revision selected by
+// opts.Revision and applies the changes in opts without registering the
+// result. The image is either the full reference in opts.Image, the
+// current image retagged with opts.ImageTag, or the current image
+// unchanged.
+func prepareTaskDef(ctx context.Context, cluster, service string, opts DeployOptions, svc *ecs.Client) (*preparedTaskDef, error) {
+	baseDef, err := resolveRevision(ctx, cluster, service, opts.Revision, svc)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	response, err := svc.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
-		TaskDefinition: &latestDef,
+		TaskDefinition: &baseDef,
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to describe task definition: %w", err)
+		return nil, fmt.Errorf("failed to describe task definition: %w", err)
 	}
 
 	if len(response.TaskDefinition.ContainerDefinitions) > 1 {
-		return "", errors.New("multiple container definitions in a single task are not supported")
+		return nil, errors.New("multiple container definitions in a single task are not supported")
 	}
 
 	containerDef, err := utils.SafeGetFirstPtr(response.TaskDefinition.ContainerDefinitions, "no container definitions found")
 	if err != nil {
-		return "", fmt.Errorf("failed to get container definition: %w", err)
+		return nil, fmt.Errorf("failed to get container definition: %w", err)
 	}
 
-	newDef := &ecs.RegisterTaskDefinitionInput{}
-	err = copier.Copy(newDef, response.TaskDefinition)
+	newDef, err := taskDefinitionInput(response.TaskDefinition, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to copy task definition: %w", err)
+		return nil, err
 	}
 
 	if containerDef.Image == nil {
-		return "", errors.New("container definition has no image specified")
+		return nil, errors.New("container definition has no image specified")
+	}
+
+	newImage, err := ParseImageReference(*containerDef.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current image: %w", err)
+	}
+
+	if opts.ImageTag != "" || opts.Image != "" {
+		newImage, err = resolveImage(*containerDef.Image, opts.ImageTag, opts.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		newDef.ContainerDefinitions[0].Image = aws.String(newImage.String())
 	}
 
-	split := strings.Split(*containerDef.Image, ":")
-	newDockerURI := fmt.Sprintf("%s:%s", split[0], dockerImageTag)
+	applyDeployOptions(newDef, opts)
 
-	newDef.ContainerDefinitions[0].Image = &newDockerURI
+	return &preparedTaskDef{
+		Input:    newDef,
+		Previous: response.TaskDefinition,
+		Image:    newImage,
+	}, nil
+}
 
-	output, err := svc.RegisterTaskDefinition(ctx, newDef)
+func registerTaskDef(ctx context.Context, input *ecs.RegisterTaskDefinitionInput, svc *ecs.Client) (string, error) {
+	output, err := svc.RegisterTaskDefinition(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to register task definition: %w", err)
 	}
@@ -76,11 +180,107 @@ func cloneTaskDef(ctx context.Context, cluster, service, dockerImageTag string,
 	return *output.TaskDefinition.TaskDefinitionArn, nil
 }
 
-func Deploy(ctx context.Context, clients *AWSClients, cluster, service, dockerImageTag string) (*DeployResult, error) {
-	// Clones the latest version of the task definition and inserts the new Docker URI.
-	TaskDefinitionArn, err := cloneTaskDef(ctx, cluster, service, dockerImageTag, clients.ECS)
+// cloneTaskDef registers a copy of the task definition revision selected by
+// opts.Revision with the changes in opts applied.
+func cloneTaskDef(ctx context.Context, cluster, service string, opts DeployOptions, svc *ecs.Client) (string, ImageReference, error) {
+	prepared, err := prepareTaskDef(ctx, cluster, service, opts, svc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone task definition: %w", err)
+		return "", ImageReference{}, err
+	}
+
+	arn, err := registerTaskDef(ctx, prepared.Input, svc)
+	if err != nil {
+		return "", ImageReference{}, err
+	}
+
+	return arn, prepared.Image, nil
+}
+
+// describeTaskDefinition returns the task definition with the given ARN
+func describeTaskDefinition(ctx context.Context, taskDefinitionArn string, svc *ecs.Client) (*types.TaskDefinition, error) {
+	response, err := svc.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: &taskDefinitionArn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe task definition %s: %w", taskDefinitionArn, err)
+	}
+
+	if response.TaskDefinition == nil {
+		return nil, fmt.Errorf("task definition %s not found", taskDefinitionArn)
+	}
+
+	return response.TaskDefinition, nil
+}
+
+// describeTaskDefinitionRevision returns a task definition revision, calling
+// AWS at most once per revision and process. A revision never changes except
+// for its status, so callers must not rely on the status. Family names
+// without a revision resolve to the latest revision and are not memoized.
+func describeTaskDefinitionRevision(ctx context.Context, taskDefinition string, svc *ecs.Client) (*types.TaskDefinition, error) {
+	_, name, _ := strings.Cut(taskDefinition, "task-definition/")
+	if name == "" {
+		name = taskDefinition
+	}
+
+	if !strings.Contains(name, ":") {
+		return describeTaskDefinition(ctx, taskDefinition, svc)
+	}
+
+	return memoized("task-definition/"+name, func() (*types.TaskDefinition, error) {
+		return describeTaskDefinition(ctx, taskDefinition, svc)
+	})
+}
+
+// deployTaskDef diffs input against the revision the service currently runs,
+// passes the changes to onChanges, registers it and points the service at it.
+// With dryRun only the changes are computed. Unless allowFamilyChange is set,
+// input must belong to the family the service runs, because moving the
+// service to another family also moves revisions, prune and --revision there.
+func deployTaskDef(ctx context.Context, clients *AWSClients, cluster, service string, input *ecs.RegisterTaskDefinitionInput, current *types.TaskDefinition, dryRun, allowFamilyChange bool, onChanges DeployChangesFunc) (*DeployResult, error) {
+	currentArn, err := serviceTaskDefinitionArn(ctx, cluster, service, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil || currentArn != deref(current.TaskDefinitionArn) {
+		current, err = describeTaskDefinitionRevision(ctx, currentArn, clients.ECS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !allowFamilyChange && deref(input.Family) != deref(current.Family) {
+		return nil, fmt.Errorf("task definition family %s does not match family %s of service %s, use --allow-family-change to move the service to it",
+			deref(input.Family), deref(current.Family), service)
+	}
+
+	proposed := &types.TaskDefinition{}
+	err = copier.CopyWithOption(proposed, input, copier.Option{DeepCopy: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy task definition: %w", err)
+	}
+
+	result := &DeployResult{
+		PreviousTaskDefinitionArn: currentArn,
+		Changes:                   diffTaskDefinitions(current, proposed),
+		DryRun:                    dryRun,
+	}
+
+	if len(input.ContainerDefinitions) > 0 {
+		result.Image = deref(input.ContainerDefinitions[0].Image)
+	}
+
+	if onChanges != nil {
+		onChanges(result.PreviousTaskDefinitionArn, result.Changes)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	TaskDefinitionArn, err := registerTaskDef(ctx, input, clients.ECS)
+	if err != nil {
+		return nil, err
 	}
 
 	updateOutput, err := clients.ECS.UpdateService(ctx, &ecs.UpdateServiceInput{
@@ -97,8 +297,36 @@ func Deploy(ctx context.Context, clients *AWSClients, cluster, service, dockerIm
 		return nil, errors.New("invalid service update response: missing service ARN")
 	}
 
-	return &DeployResult{
-		TaskDefinitionArn: TaskDefinitionArn,
-		ServiceArn:        *updateOutput.Service.ServiceArn,
-	}, nil
+	result.TaskDefinitionArn = TaskDefinitionArn
+	result.ServiceArn = *updateOutput.Service.ServiceArn
+
+	return result, nil
+}
+
+// Deploy registers a new task definition revision with the changes in opts
+// and points the service at it. The reported changes are relative to the
+// revision the service currently runs. With dryRun nothing is registered or
+// updated, only the changes are computed.
+func Deploy(ctx context.Context, clients *AWSClients, cluster, service string, opts DeployOptions, dryRun bool) (*DeployResult, error) {
+	// Clones the selected revision of the task definition and applies the requested changes.
+	prepared, err := prepareTaskDef(ctx, cluster, service, opts, clients.ECS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone task definition: %w", err)
+	}
+
+	return deployTaskDef(ctx, clients, cluster, service, prepared.Input, prepared.Previous, dryRun, false, opts.OnChanges)
+}
+
+// DeployTaskDefinitionFile registers the task definition in a JSON or YAML
+// file and points the service at it. See LoadTaskDefinitionFile for the
+// accepted document shape and placeholders. onChanges, if set, is called with
+// the changes before anything is registered or updated. A file of another
+// family than the service runs is refused unless allowFamilyChange is set.
+func DeployTaskDefinitionFile(ctx context.Context, clients *AWSClients, cluster, service, path string, vars map[string]string, dryRun, allowFamilyChange bool, onChanges DeployChangesFunc) (*DeployResult, error) {
+	input, err := LoadTaskDefinitionFile(path, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployTaskDef(ctx, clients, cluster, service, input, nil, dryRun, allowFamilyChange, onChanges)
 }
@@ -0,0 +1,226 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// documentFieldNames lists the API names that do not follow the simple
+// lower-camel-case conversion of the SDK field name.
+var documentFieldNames = map[string]string{
+	"FSxWindowsFileServerVolumeConfiguration": "fsxWindowsFileServerVolumeConfiguration",
+}
+
+// documentField is a single key of an ordered document
+type documentField struct {
+	Key   string
+	Value any
+}
+
+// orderedDocument is a JSON/YAML object that keeps the field order of the
+// SDK struct it was built from, so exported documents read like the AWS
+// console and CLI output instead of being sorted alphabetically.
+type orderedDocument []documentField
+
+func (d orderedDocument) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+
+	buffer.WriteByte('{')
+
+	for i, field := range d {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+func (d orderedDocument) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, field := range d {
+		value := &yaml.Node{}
+
+		err := value.Encode(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Key}, value)
+	}
+
+	return node, nil
+}
+
+// documentFieldName converts an SDK field name to the camelCase name used by
+// the ECS API, e.g. ContainerDefinitions to containerDefinitions.
+func documentFieldName(name string) string {
+	if mapped, ok := documentFieldNames[name]; ok {
+		return mapped
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+
+	return string(runes)
+}
+
+// toDocument converts an SDK value into plain values, maps and ordered
+// documents that marshal to the ECS API representation. Nil pointers, empty
+// collections and zero scalars are omitted; values behind pointers are kept
+// even when zero since they were set explicitly.
+func toDocument(value reflect.Value) (any, bool) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil, false
+		}
+
+		if t, ok := value.Interface().(*time.Time); ok {
+			return t.Format(time.RFC3339), true
+		}
+
+		converted, ok := toDocument(value.Elem())
+		if !ok {
+			// Keep explicitly set zero scalars such as essential: false
+			return value.Elem().Interface(), value.Elem().Kind() != reflect.Struct
+		}
+
+		return converted, true
+	case reflect.Struct:
+		document := orderedDocument{}
+
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			converted, ok := toDocument(value.Field(i))
+			if !ok {
+				continue
+			}
+
+			document = append(document, documentField{Key: documentFieldName(field.Name), Value: converted})
+		}
+
+		return document, len(document) > 0
+	case reflect.Slice:
+		if value.Len() == 0 {
+			return nil, false
+		}
+
+		items := make([]any, 0, value.Len())
+		for i := range value.Len() {
+			converted, ok := toDocument(value.Index(i))
+			if !ok {
+				converted = value.Index(i).Interface()
+			}
+
+			items = append(items, converted)
+		}
+
+		return items, true
+	case reflect.Map:
+		if value.Len() == 0 {
+			return nil, false
+		}
+
+		keys := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			keys = append(keys, key.String())
+		}
+
+		sort.Strings(keys)
+
+		document := orderedDocument{}
+		for _, key := range keys {
+			converted, ok := toDocument(value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key())))
+			if !ok {
+				converted = ""
+			}
+
+			document = append(document, documentField{Key: key, Value: converted})
+		}
+
+		return document, true
+	case reflect.String:
+		return value.String(), value.String() != ""
+	case reflect.Bool:
+		return value.Bool(), value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), value.Int() != 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), value.Float() != 0
+	default:
+		return nil, false
+	}
+}
+
+// encodeDocument renders an SDK value as JSON or YAML using the ECS API field
+// names.
+func encodeDocument(value any, format string) ([]byte, error) {
+	document, _ := toDocument(reflect.ValueOf(value))
+
+	switch strings.ToLower(format) {
+	case DocumentFormatJSON:
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+
+		return append(data, '\n'), nil
+	case DocumentFormatYAML:
+		var buffer bytes.Buffer
+
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+
+		err := encoder.Encode(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+
+		return buffer.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported document format %q", format)
+	}
+}
@@ -27,9 +27,9 @@ import (
 )
 
 func describeTask(ctx context.Context, client *ecs.Client, taskArn *string) (TaskDefinition, error) {
-	resp, err := client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: taskArn})
+	taskDef, err := describeTaskDefinitionRevision(ctx, *taskArn, client)
 	if err != nil {
-		return TaskDefinition{}, fmt.Errorf("failed to describe task definition: %w", err)
+		return TaskDefinition{}, err
 	}
 
 	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, client, *taskArn)
@@ -42,22 +42,22 @@ func describeTask(ctx context.Context, client *ecs.Client, taskArn *string) (Tas
 	output.LogGroup = logGroup
 	output.LogStreamPrefix = logStreamPrefix
 
-	if resp.TaskDefinition.Cpu == nil {
+	if taskDef.Cpu == nil {
 		return TaskDefinition{}, fmt.Errorf("task definition has no CPU specification: %s", *taskArn)
 	}
-	if resp.TaskDefinition.Memory == nil {
+	if taskDef.Memory == nil {
 		return TaskDefinition{}, fmt.Errorf("task definition has no memory specification: %s", *taskArn)
 	}
 
-	output.Cpu = *resp.TaskDefinition.Cpu
-	output.Memory = *resp.TaskDefinition.Memory
+	output.Cpu = *taskDef.Cpu
+	output.Memory = *taskDef.Memory
 
 	// Extract RequiresCompatibilities - required field
-	if len(resp.TaskDefinition.RequiresCompatibilities) == 0 {
+	if len(taskDef.RequiresCompatibilities) == 0 {
 		return TaskDefinition{}, fmt.Errorf("task definition has no compatibility requirements: %s", *taskArn)
 	}
-	compatibilities := make([]string, len(resp.TaskDefinition.RequiresCompatibilities))
-	for i, compat := range resp.TaskDefinition.RequiresCompatibilities {
+	compatibilities := make([]string, len(taskDef.RequiresCompatibilities))
+	for i, compat := range taskDef.RequiresCompatibilities {
 		compatibilities[i] = string(compat)
 	}
 	output.RequiresCompatibilities = compatibilities
@@ -176,7 +176,7 @@ func waitForTaskCompletion(ctx context.Context, clients *AWSClients, cluster str
 	return nil
 }
 
-func Execute(ctx context.Context, clients *AWSClients, cluster, service string, cmd []string, waitForCompletion bool, dockerImageTag string, cpuOverride, memoryOverride string) (*ExecuteResult, error) {
+func Execute(ctx context.Context, clients *AWSClients, cluster, service string, cmd []string, waitForCompletion bool, dockerImageTag string, cpuOverride, memoryOverride, revision string) (*ExecuteResult, error) {
 	// Describe the service to get its configuration
 	resp, err := clients.ECS.DescribeServices(ctx, &ecs.DescribeServicesInput{
 		Cluster:  &cluster,
@@ -191,14 +191,13 @@ func Execute(ctx context.Context, clients *AWSClients, cluster, service string,
 		return nil, fmt.Errorf("failed to get service information: %w", err)
 	}
 
-	// Fetch the latest task definition
-	latestTaskDefArn, err := latestTaskDefinitionArn(ctx, cluster, service, clients.ECS)
+	// Resolve the task definition revision to run
+	taskDefArn, err := resolveRevision(ctx, cluster, service, revision, clients.ECS)
 	if err != nil {
 		return nil, fmt.Errorf("error getting task definition for service %s: %w", service, err)
 	}
 
 	// Extract network configuration if available
-	taskDefArn := latestTaskDefArn
 
 	var subnets []string
 
@@ -225,7 +224,7 @@ func Execute(ctx context.Context, clients *AWSClients, cluster, service string,
 	newTaskDefCreated := false
 
 	if dockerImageTag != "" {
-		taskDef, err = cloneTaskDef(ctx, cluster, service, dockerImageTag, clients.ECS)
+		taskDef, _, err = cloneTaskDef(ctx, cluster, service, DeployOptions{ImageTag: dockerImageTag, Revision: revision}, clients.ECS)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,126 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageReference is a parsed container image reference of the form
+// [registry[:port]/]repository[:tag][@digest]
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference parses an image reference such as
+// "registry:5000/team/app:v1" or "app@sha256:...". The first path component
+// is treated as the registry when it contains a dot or a port, or is
+// "localhost", following the Docker conventions.
+func ParseImageReference(value string) (ImageReference, error) {
+	ref := ImageReference{}
+	remainder := strings.TrimSpace(value)
+
+	if remainder == "" {
+		return ImageReference{}, fmt.Errorf("invalid image reference %q: empty value", value)
+	}
+
+	if before, digest, ok := strings.Cut(remainder, "@"); ok {
+		if !strings.Contains(digest, ":") {
+			return ImageReference{}, fmt.Errorf("invalid image reference %q: digest must be in the form algorithm:hex", value)
+		}
+
+		ref.Digest = digest
+		remainder = before
+	}
+
+	if first, rest, ok := strings.Cut(remainder, "/"); ok {
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			remainder = rest
+		}
+	}
+
+	// Any colon left after the registry was removed separates the tag
+	if before, tag, ok := strings.Cut(remainder, ":"); ok {
+		if tag == "" || strings.Contains(tag, "/") {
+			return ImageReference{}, fmt.Errorf("invalid image reference %q: malformed tag", value)
+		}
+
+		ref.Tag = tag
+		remainder = before
+	}
+
+	if remainder == "" {
+		return ImageReference{}, fmt.Errorf("invalid image reference %q: missing repository", value)
+	}
+
+	ref.Repository = remainder
+
+	return ref, nil
+}
+
+// Name returns the registry and repository without tag or digest
+func (r ImageReference) Name() string {
+	if r.Registry == "" {
+		return r.Repository
+	}
+
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the full image reference
+func (r ImageReference) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(r.Name())
+
+	if r.Tag != "" {
+		builder.WriteString(":" + r.Tag)
+	}
+
+	if r.Digest != "" {
+		builder.WriteString("@" + r.Digest)
+	}
+
+	return builder.String()
+}
+
+// WithTag returns a copy of the reference pointing at the given tag. Any
+// digest is dropped since it would pin the image regardless of the tag.
+func (r ImageReference) WithTag(tag string) ImageReference {
+	r.Tag = tag
+	r.Digest = ""
+
+	return r
+}
+
+// resolveImage returns the image a new task definition revision should use.
+// A full image reference takes precedence; otherwise the current image is
+// retagged with imageTag.
+func resolveImage(currentImage, imageTag, image string) (ImageReference, error) {
+	if image != "" {
+		return ParseImageReference(image)
+	}
+
+	current, err := ParseImageReference(currentImage)
+	if err != nil {
+		return ImageReference{}, fmt.Errorf("failed to parse current image: %w", err)
+	}
+
+	return current.WithTag(imageTag), nil
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 )
 
 func getClusterArns(ctx context.Context, svc *ecs.Client) ([]string, error) {
@@ -55,45 +55,23 @@ func getServiceArns(ctx context.Context, svc *ecs.Client, cluster string) ([]str
 	return serviceArns, nil
 }
 
+// getTaskDetails returns the running tasks of the service
 func getTaskDetails(ctx context.Context, svc *ecs.Client, cluster string, service string) ([]TaskInfo, error) {
-	var allTaskArns []string
-	input := &ecs.ListTasksInput{
-		Cluster:     aws.String(cluster),
-		ServiceName: aws.String(service),
-	}
-
-	for {
-		listTasksOutput, err := svc.ListTasks(ctx, input)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list tasks for service %s in cluster %s: %w", service, cluster, err)
-		}
-
-		allTaskArns = append(allTaskArns, listTasksOutput.TaskArns...)
-
-		if listTasksOutput.NextToken == nil {
-			break
-		}
-
-		input.NextToken = listTasksOutput.NextToken
-	}
-
-	arns := append([]string{}, allTaskArns...)
-
-	describeTasksOutput, err := svc.DescribeTasks(ctx, &ecs.DescribeTasksInput{
-		Cluster: aws.String(cluster),
-		Tasks:   arns,
-	})
+	describedTasks, err := describeServiceTasks(ctx, cluster, service, types.DesiredStatusRunning, svc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe tasks for service %s in cluster %s: %w", service, cluster, err)
+		return nil, fmt.Errorf("failed to list tasks for service %s in cluster %s: %w", service, cluster, err)
 	}
 
 	tasks := []TaskInfo{}
 
-	for _, task := range describeTasksOutput.Tasks {
+	for _, task := range describedTasks {
 		var runningTime string
 
+		var startedAt time.Time
+
 		if task.StartedAt != nil {
-			duration := time.Since(*task.StartedAt)
+			startedAt = *task.StartedAt
+			duration := time.Since(startedAt)
 			runningTime = formatRunningTime(duration)
 		} else {
 			runningTime = "Unknown"
@@ -105,18 +83,21 @@ func getTaskDetails(ctx context.Context, svc *ecs.Client, cluster string, servic
 		}
 
 		tasks = append(tasks, TaskInfo{
-			ID:          taskID,
-			CPU:         *task.Cpu,
-			Memory:      *task.Memory,
-			RunningTime: runningTime,
+			ID:           taskID,
+			Arn:          *task.TaskArn,
+			CPU:          deref(task.Cpu),
+			Memory:       deref(task.Memory),
+			RunningTime:  runningTime,
+			StartedAt:    startedAt,
+			HealthStatus: string(task.HealthStatus),
 		})
 	}
 
 	return tasks, nil
 }
 
-// GetClusters returns structured data about ECS clusters, services, and optionally tasks
-func GetClusters(ctx context.Context, clients *AWSClients, includeTasks bool) ([]ClusterInfo, error) {
+// listClusters returns the clusters of the region with their services
+func listClusters(ctx context.Context, clients *AWSClients) ([]ClusterInfo, error) {
 	clusterArns, err := getClusterArns(ctx, clients.ECS)
 	if err != nil {
 		return nil, err
@@ -125,6 +106,11 @@ func GetClusters(ctx context.Context, clients *AWSClients, includeTasks bool) ([
 	clusters := []ClusterInfo{}
 
 	for _, clusterArn := range clusterArns {
+		clusterName, err := extractARNResource(clusterArn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract cluster name from ARN %s: %w", clusterArn, err)
+		}
+
 		serviceArns, err := getServiceArns(ctx, clients.ECS, clusterArn)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list services for cluster %s: %w", clusterArn, err)
@@ -138,32 +124,11 @@ func GetClusters(ctx context.Context, clients *AWSClients, includeTasks bool) ([
 				return nil, fmt.Errorf("failed to extract service name from ARN %s: %w", serviceArn, err)
 			}
 
-			clusterName, err := extractARNResource(clusterArn)
-			if err != nil {
-				return nil, fmt.Errorf("failed to extract cluster name from ARN %s: %w", clusterArn, err)
-			}
-
-			service := ServiceInfo{
+			services = append(services, ServiceInfo{
 				Name:        serviceName,
 				ClusterName: clusterName,
 				Tasks:       []TaskInfo{},
-			}
-
-			if includeTasks {
-				tasks, err := getTaskDetails(ctx, clients.ECS, clusterName, serviceName)
-				if err != nil {
-					return nil, fmt.Errorf("failed to list tasks for service %s/%s: %w", clusterName, serviceName, err)
-				}
-				service.Tasks = tasks
-			}
-
-			services = append(services, service)
-		}
-
-		// Extract cluster name from ARN
-		clusterName, err := extractARNResource(clusterArn)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract cluster name from ARN %s: %w", clusterArn, err)
+			})
 		}
 
 		clusters = append(clusters, ClusterInfo{
@@ -174,3 +139,30 @@ func GetClusters(ctx context.Context, clients *AWSClients, includeTasks bool) ([
 
 	return clusters, nil
 }
+
+// GetClusters returns structured data about ECS clusters, services, and optionally tasks
+func GetClusters(ctx context.Context, clients *AWSClients, includeTasks bool) ([]ClusterInfo, error) {
+	clusters, err := listClusters(ctx, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeTasks {
+		return clusters, nil
+	}
+
+	for i := range clusters {
+		for j := range clusters[i].Services {
+			service := &clusters[i].Services[j]
+
+			tasks, err := getTaskDetails(ctx, clients.ECS, service.ClusterName, service.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tasks for service %s/%s: %w", service.ClusterName, service.Name, err)
+			}
+
+			service.Tasks = tasks
+		}
+	}
+
+	return clusters, nil
+}
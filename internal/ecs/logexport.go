@@ -0,0 +1,433 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+const (
+	// LogExportFormatText writes one "timestamp message" line per event
+	LogExportFormatText = "text"
+	// LogExportFormatNDJSON writes one JSON object per event
+	LogExportFormatNDJSON = "ndjson"
+
+	// logExportCheckpointFile is the name of the checkpoint kept in the output
+	// directory while an export is in progress.
+	logExportCheckpointFile = ".runecs-export.json"
+)
+
+// LogExportOptions configures a log export
+type LogExportOptions struct {
+	OutputDir  string
+	StartTime  time.Time
+	EndTime    time.Time
+	Format     string // LogExportFormatText or LogExportFormatNDJSON
+	Gzip       bool
+	SingleFile bool
+	// Revision selects the task definition whose log configuration is
+	// exported, see ValidateRevision; empty uses the revision the service runs
+	Revision string
+	// Progress is called after every page of events has been written.
+	Progress func(events int, bytes int64)
+}
+
+// logExportCheckpoint records how far an export has progressed. File sizes
+// are stored so that a page written only partially before an interruption
+// can be truncated away on resume.
+type logExportCheckpoint struct {
+	LogGroup        string                  `json:"logGroup"`
+	LogStreamPrefix string                  `json:"logStreamPrefix"`
+	StartTime       int64                   `json:"startTime"`
+	EndTime         int64                   `json:"endTime"`
+	Format          string                  `json:"format"`
+	Gzip            bool                    `json:"gzip"`
+	SingleFile      bool                    `json:"singleFile"`
+	NextToken       string                  `json:"nextToken"`
+	Files           map[string]ExportedFile `json:"files"`
+}
+
+type ndjsonLogEvent struct {
+	Timestamp string `json:"timestamp"`
+	Stream    string `json:"stream"`
+	TaskID    string `json:"taskId"`
+	Message   string `json:"message"`
+}
+
+func (c *logExportCheckpoint) matches(other *logExportCheckpoint) bool {
+	return c.LogGroup == other.LogGroup &&
+		c.LogStreamPrefix == other.LogStreamPrefix &&
+		c.Format == other.Format &&
+		c.Gzip == other.Gzip &&
+		c.SingleFile == other.SingleFile
+}
+
+func readLogExportCheckpoint(path string) (*logExportCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	checkpoint := &logExportCheckpoint{}
+	err = json.Unmarshal(data, checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+
+	return checkpoint, nil
+}
+
+// writeLogExportCheckpoint replaces the checkpoint atomically so an
+// interruption never leaves a half-written file behind.
+func writeLogExportCheckpoint(path string, checkpoint *logExportCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", tmp, err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed to replace checkpoint %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// taskIDFromStreamName returns the last segment of an awslogs stream name,
+// which is the ID of the task that produced it.
+func taskIDFromStreamName(streamName string) string {
+	if idx := strings.LastIndex(streamName, "/"); idx != -1 {
+		return streamName[idx+1:]
+	}
+
+	return streamName
+}
+
+func logExportFileName(service, streamName string, opts LogExportOptions) string {
+	name := service
+	if !opts.SingleFile {
+		name = taskIDFromStreamName(streamName)
+	}
+
+	if opts.Format == LogExportFormatNDJSON {
+		name += ".ndjson"
+	} else {
+		name += ".log"
+	}
+
+	if opts.Gzip {
+		name += ".gz"
+	}
+
+	return name
+}
+
+func formatLogExportEvent(entry LogEntry, opts LogExportOptions) ([]byte, error) {
+	timestamp := time.UnixMilli(entry.Timestamp).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+
+	if opts.Format == LogExportFormatNDJSON {
+		line, err := json.Marshal(ndjsonLogEvent{
+			Timestamp: timestamp,
+			Stream:    entry.StreamName,
+			TaskID:    taskIDFromStreamName(entry.StreamName),
+			Message:   entry.Message,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode log event: %w", err)
+		}
+
+		return append(line, '\n'), nil
+	}
+
+	message := strings.TrimRight(entry.Message, "\n")
+	if opts.SingleFile {
+		return fmt.Appendf(nil, "%s %s %s\n", timestamp, entry.StreamName, message), nil
+	}
+
+	return fmt.Appendf(nil, "%s %s\n", timestamp, message), nil
+}
+
+// appendLogExportFile appends the encoded events to a file. With gzip every
+// call writes a complete gzip member, which keeps the file valid at every
+// checkpoint since concatenated members form a valid gzip stream.
+func appendLogExportFile(path string, truncate bool, lines [][]byte, compress bool) (int64, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var writer io.Writer = file
+
+	var gzipWriter *gzip.Writer
+	if compress {
+		gzipWriter = gzip.NewWriter(file)
+		writer = gzipWriter
+	}
+
+	for _, line := range lines {
+		_, err = writer.Write(line)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	if gzipWriter != nil {
+		err = gzipWriter.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to compress %s: %w", path, err)
+		}
+	}
+
+	err = file.Sync()
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return info.Size(), nil
+}
+
+// ExportServiceLogs copies every log event of the service's container streams
+// within the requested time window into files in opts.OutputDir. Progress is
+// checkpointed after every page, so an interrupted export resumes where it
+// stopped when started again with the same output directory.
+func ExportServiceLogs(ctx context.Context, clients *AWSClients, cluster, service string, opts LogExportOptions) (*LogExportResult, error) {
+	if opts.Format != LogExportFormatText && opts.Format != LogExportFormatNDJSON {
+		return nil, fmt.Errorf("unsupported export format %q", opts.Format)
+	}
+
+	taskDefArn, err := resolveRevision(ctx, cluster, service, opts.Revision, clients.ECS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task definition for service %s: %w", service, err)
+	}
+
+	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, clients.ECS, taskDefArn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log configuration: %w", err)
+	}
+
+	if logGroup == "" || logStreamPrefix == "" {
+		return nil, fmt.Errorf("service %s does not have CloudWatch logging configured", service)
+	}
+
+	err = os.MkdirAll(opts.OutputDir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", opts.OutputDir, err)
+	}
+
+	checkpointPath := filepath.Join(opts.OutputDir, logExportCheckpointFile)
+
+	checkpoint := &logExportCheckpoint{
+		LogGroup:        logGroup,
+		LogStreamPrefix: fmt.Sprintf("%s/%s/", logStreamPrefix, containerName),
+		StartTime:       opts.StartTime.UnixMilli(),
+		EndTime:         opts.EndTime.UnixMilli(),
+		Format:          opts.Format,
+		Gzip:            opts.Gzip,
+		SingleFile:      opts.SingleFile,
+		Files:           map[string]ExportedFile{},
+	}
+
+	result := &LogExportResult{
+		LogGroup:        checkpoint.LogGroup,
+		LogStreamPrefix: checkpoint.LogStreamPrefix,
+		OutputDir:       opts.OutputDir,
+		CheckpointPath:  checkpointPath,
+	}
+
+	previous, err := readLogExportCheckpoint(checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files already started in this export; everything else is truncated on
+	// first write so leftovers from older exports are not mixed in.
+	touched := map[string]bool{}
+
+	if previous != nil {
+		if !previous.matches(checkpoint) {
+			return nil, fmt.Errorf("%s belongs to a different export, remove it or choose another output directory", checkpointPath)
+		}
+
+		// Resume the original time window rather than a new relative one
+		checkpoint = previous
+		result.Resumed = true
+
+		for name, file := range checkpoint.Files {
+			err = os.Truncate(filepath.Join(opts.OutputDir, name), file.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore %s from checkpoint: %w", name, err)
+			}
+
+			touched[name] = true
+		}
+	}
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:        aws.String(checkpoint.LogGroup),
+		LogStreamNamePrefix: aws.String(checkpoint.LogStreamPrefix),
+		StartTime:           aws.Int64(checkpoint.StartTime),
+		EndTime:             aws.Int64(checkpoint.EndTime),
+	}
+
+	if checkpoint.NextToken != "" {
+		input.NextToken = aws.String(checkpoint.NextToken)
+	}
+
+	// summarize fills in the totals written so far, also for interrupted
+	// exports so the caller can report them.
+	summarize := func() {
+		result.Files = sortedExportedFiles(checkpoint.Files)
+		result.Events, result.Bytes = exportTotals(checkpoint.Files)
+	}
+
+	for {
+		output, err := clients.CloudWatchLogs.FilterLogEvents(ctx, input)
+		if err != nil {
+			summarize()
+
+			return result, fmt.Errorf("failed to fetch log events from %s: %w", checkpoint.LogGroup, err)
+		}
+
+		lines := map[string][][]byte{}
+
+		for _, event := range output.Events {
+			if event.LogStreamName == nil || event.Message == nil || event.Timestamp == nil {
+				continue
+			}
+
+			entry := LogEntry{
+				StreamName: *event.LogStreamName,
+				Message:    *event.Message,
+				Timestamp:  *event.Timestamp,
+			}
+
+			line, err := formatLogExportEvent(entry, opts)
+			if err != nil {
+				summarize()
+
+				return result, err
+			}
+
+			name := logExportFileName(service, entry.StreamName, opts)
+			lines[name] = append(lines[name], line)
+		}
+
+		for name, fileLines := range lines {
+			size, err := appendLogExportFile(filepath.Join(opts.OutputDir, name), !touched[name], fileLines, opts.Gzip)
+			if err != nil {
+				summarize()
+
+				return result, err
+			}
+
+			touched[name] = true
+
+			file := checkpoint.Files[name]
+			file.Name = name
+			file.Events += len(fileLines)
+			file.Bytes = size
+			checkpoint.Files[name] = file
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+
+		input.NextToken = output.NextToken
+		checkpoint.NextToken = *output.NextToken
+
+		err = writeLogExportCheckpoint(checkpointPath, checkpoint)
+		if err != nil {
+			summarize()
+
+			return result, err
+		}
+
+		if opts.Progress != nil {
+			events, bytes := exportTotals(checkpoint.Files)
+			opts.Progress(events, bytes)
+		}
+	}
+
+	summarize()
+
+	err = os.Remove(checkpointPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return result, fmt.Errorf("failed to remove checkpoint %s: %w", checkpointPath, err)
+	}
+
+	result.Completed = true
+
+	return result, nil
+}
+
+func exportTotals(files map[string]ExportedFile) (int, int64) {
+	events := 0
+
+	var bytes int64
+
+	for _, file := range files {
+		events += file.Events
+		bytes += file.Bytes
+	}
+
+	return events, bytes
+}
+
+func sortedExportedFiles(files map[string]ExportedFile) []ExportedFile {
+	sorted := make([]ExportedFile, 0, len(files))
+	for _, file := range files {
+		sorted = append(sorted, file)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
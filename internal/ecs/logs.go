@@ -17,13 +17,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"runecs.io/v1/internal/utils"
 )
 
@@ -37,14 +37,12 @@ const (
 )
 
 func getLogStreamPrefix(ctx context.Context, client *ecs.Client, taskDefinitionArn string) (string, string, string, error) {
-	resp, err := client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
-		TaskDefinition: &taskDefinitionArn,
-	})
+	taskDef, err := describeTaskDefinitionRevision(ctx, taskDefinitionArn, client)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to describe task definition %s: %w", taskDefinitionArn, err)
+		return "", "", "", err
 	}
 
-	containerDef, err := utils.SafeGetFirstPtr(resp.TaskDefinition.ContainerDefinitions, "no container definitions found")
+	containerDef, err := utils.SafeGetFirstPtr(taskDef.ContainerDefinitions, "no container definitions found")
 	if err != nil {
 		return "", "", "", fmt.Errorf("failed to get container definition from task %s: %w", taskDefinitionArn, err)
 	}
@@ -65,13 +63,15 @@ func getLogStreamPrefix(ctx context.Context, client *ecs.Client, taskDefinitionA
 	return logGroup, logStreamPrefix, containerName, nil
 }
 
-func GetServiceLogs(ctx context.Context, clients *AWSClients, cluster, service string, startTime *int64) ([]LogEntry, error) {
-	latestTaskDefArn, err := latestTaskDefinitionArn(ctx, cluster, service, clients.ECS)
+// GetServiceLogs returns the service's logs since startTime, read with the log
+// configuration of the given task definition revision (see ValidateRevision).
+func GetServiceLogs(ctx context.Context, clients *AWSClients, cluster, service, revision string, startTime *int64) ([]LogEntry, error) {
+	taskDefArn, err := resolveRevision(ctx, cluster, service, revision, clients.ECS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest task definition for service %s: %w", service, err)
+		return nil, fmt.Errorf("failed to get task definition for service %s: %w", service, err)
 	}
 
-	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, clients.ECS, latestTaskDefArn)
+	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, clients.ECS, taskDefArn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get log configuration: %w", err)
 	}
@@ -171,7 +171,21 @@ func fetchTaskLogs(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 	return logs, nil
 }
 
-func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGroupIdentifiers []string, logStreamPrefixes []string) (<-chan LogEntry, func(), error) {
+// liveTailRefreshInterval is how often a service live tail checks whether the
+// service has moved to a task definition with a different log configuration.
+const liveTailRefreshInterval = 30 * time.Second
+
+// liveTailTarget identifies the log group and stream prefix a live tail
+// session is attached to.
+type liveTailTarget struct {
+	LogGroupArn     string
+	LogStreamPrefix string
+}
+
+// runLiveTail starts a CloudWatch Live Tail session and forwards its events to
+// logs and notices until the stream ends or ctx is cancelled. The returned
+// channel is closed once the session has been torn down.
+func runLiveTail(ctx context.Context, cwClient *cloudwatchlogs.Client, logGroupIdentifiers []string, logStreamPrefixes []string, logs chan<- LogEntry, notices chan<- string) (<-chan struct{}, error) {
 	startLiveTailInput := &cloudwatchlogs.StartLiveTailInput{
 		LogGroupIdentifiers:   logGroupIdentifiers,
 		LogStreamNamePrefixes: logStreamPrefixes,
@@ -179,14 +193,14 @@ func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 
 	response, err := cwClient.StartLiveTail(ctx, startLiveTailInput)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to start live tail: %w", err)
+		return nil, fmt.Errorf("failed to start live tail: %w", err)
 	}
 
 	stream := response.GetStream()
-	logChan := make(chan LogEntry, 100)
+	done := make(chan struct{})
 
 	go func() {
-		defer close(logChan)
+		defer close(done)
 		defer func() {
 			err := stream.Close()
 			if err != nil {
@@ -198,6 +212,10 @@ func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 
 		eventsChan := stream.Events()
 
+		// Sampling is reported on every update while the volume stays high, so
+		// only warn when a sampled period starts.
+		sampled := false
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -211,6 +229,17 @@ func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 				case *types.StartLiveTailResponseStreamMemberSessionStart:
 					continue
 				case *types.StartLiveTailResponseStreamMemberSessionUpdate:
+					updateSampled := e.Value.SessionMetadata != nil && e.Value.SessionMetadata.Sampled
+					if updateSampled && !sampled {
+						notice := "CloudWatch is sampling live tail results, some log lines are not shown"
+						select {
+						case notices <- notice:
+						case <-ctx.Done():
+							return
+						}
+					}
+					sampled = updateSampled
+
 					for _, logEvent := range e.Value.SessionResults {
 						if logEvent.Message == nil || logEvent.Timestamp == nil || logEvent.LogStreamName == nil {
 							continue
@@ -221,7 +250,7 @@ func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 							Timestamp:  *logEvent.Timestamp,
 						}
 						select {
-						case logChan <- logEntry:
+						case logs <- logEntry:
 						case <-ctx.Done():
 							return
 						}
@@ -251,50 +280,201 @@ func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGrou
 		}
 	}()
 
-	closeFunc := func() {
-		err := stream.Close()
-		if err != nil {
-			slog.Error("failed to close CloudWatch logs stream",
-				"error", err,
-				"context", "CloudWatch logs live tail stream manual close")
-		}
-	}
-
-	return logChan, closeFunc, nil
+	return done, nil
 }
 
-func TailServiceLogs(ctx context.Context, clients *AWSClients, cluster, service string) (<-chan LogEntry, func(), error) {
-	latestTaskDefArn, err := latestTaskDefinitionArn(ctx, cluster, service, clients.ECS)
+// TailLogGroups starts a live tail session for the given log groups and stream
+// prefixes.
+func TailLogGroups(ctx context.Context, cwClient *cloudwatchlogs.Client, logGroupIdentifiers []string, logStreamPrefixes []string) (*LiveTail, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	logs := make(chan LogEntry, 100)
+	notices := make(chan string, 10)
+
+	done, err := runLiveTail(ctx, cwClient, logGroupIdentifiers, logStreamPrefixes, logs, notices)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get latest task definition for service %s: %w", service, err)
+		cancel()
+
+		return nil, err
 	}
 
-	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, clients.ECS, latestTaskDefArn)
+	go func() {
+		defer close(logs)
+		defer close(notices)
+
+		<-done
+	}()
+
+	return &LiveTail{Logs: logs, Notices: notices, cancel: cancel}, nil
+}
+
+// resolveLiveTailTarget returns the log group ARN and stream prefix for the
+// container of the given task definition.
+func resolveLiveTailTarget(ctx context.Context, client *ecs.Client, taskDefinitionArn, partition, accountID, region string) (liveTailTarget, error) {
+	logGroup, logStreamPrefix, containerName, err := getLogStreamPrefix(ctx, client, taskDefinitionArn)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get log configuration: %w", err)
+		return liveTailTarget{}, fmt.Errorf("failed to get log configuration: %w", err)
 	}
 
 	if logGroup == "" || logStreamPrefix == "" {
-		return nil, nil, fmt.Errorf("service %s does not have CloudWatch logging configured", service)
+		return liveTailTarget{}, fmt.Errorf("task definition %s does not have CloudWatch logging configured", taskDefinitionArn)
 	}
 
-	// Get the account ID using STS
-	identity, err := clients.STS.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	return liveTailTarget{
+		LogGroupArn: buildARN(partition, "logs", region, accountID, "log-group:"+logGroup),
+		// Use the prefix to capture all streams for this service's containers
+		LogStreamPrefix: fmt.Sprintf("%s/%s/", logStreamPrefix, containerName),
+	}, nil
+}
+
+// TailServiceLogs live tails the logs of a service using the log
+// configuration of the given task definition revision (see
+// ValidateRevision). When the tail follows the revision the service runs,
+// the service is periodically re-described, and when it moves to a task
+// definition with a different log group, stream prefix or container name the
+// session is restarted against the new configuration.
+func TailServiceLogs(ctx context.Context, clients *AWSClients, cluster, service, revision string) (*LiveTail, error) {
+	taskDefArn, err := resolveRevision(ctx, cluster, service, revision, clients.ECS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task definition for service %s: %w", service, err)
+	}
+
+	// A pinned revision keeps its log configuration after deploys
+	followService := revision == "" || revision == RevisionService || revision == RevisionCurrent
+	serviceTaskDefArn := taskDefArn
+
+	identity, err := callerIdentity(ctx, clients)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get caller identity: %w", err)
+		return nil, err
 	}
 
 	// Extract partition from caller's ARN to handle different AWS partitions
 	partition, err := extractPartitionFromARN(*identity.Arn)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to extract partition from caller ARN: %w", err)
+		return nil, fmt.Errorf("failed to extract partition from caller ARN: %w", err)
+	}
+
+	target, err := resolveLiveTailTarget(ctx, clients.ECS, taskDefArn, partition, *identity.Account, clients.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve logs for service %s: %w", service, err)
 	}
 
-	// Construct the LogGroup ARN with correct partition
-	logGroupArn := buildARN(partition, "logs", clients.Region, *identity.Account, "log-group:"+logGroup)
+	ctx, cancel := context.WithCancel(ctx)
+
+	logs := make(chan LogEntry, 100)
+	notices := make(chan string, 10)
+
+	sessionCtx, stopSession := context.WithCancel(ctx)
+
+	done, err := runLiveTail(sessionCtx, clients.CloudWatchLogs, []string{target.LogGroupArn}, []string{target.LogStreamPrefix}, logs, notices)
+	if err != nil {
+		stopSession()
+		cancel()
+
+		return nil, err
+	}
+
+	notify := func(notice string) {
+		select {
+		case notices <- notice:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(logs)
+		defer close(notices)
+		defer func() {
+			stopSession()
+			if done != nil {
+				<-done
+			}
+		}()
+
+		// A nil channel never fires, so a pinned revision is never refreshed
+		var refresh <-chan time.Time
 
-	// Use LogStreamNamePrefixes to capture all streams for this service's containers
-	logStreamPrefixPattern := fmt.Sprintf("%s/%s/", logStreamPrefix, containerName)
+		if followService {
+			ticker := time.NewTicker(liveTailRefreshInterval)
+			defer ticker.Stop()
+
+			refresh = ticker.C
+		}
+
+		// Failures are retried on every tick but reported only when they
+		// start, so an outage does not repeat the same notice
+		refreshFailing := false
+		unresolvedArn := ""
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-refresh:
+			}
+
+			taskDefArn, err := serviceTaskDefinitionArn(ctx, cluster, service, clients.ECS)
+			if err != nil {
+				if !refreshFailing {
+					notify(fmt.Sprintf("failed to refresh service %s, retrying: %v", service, err))
+				}
+
+				refreshFailing = true
+
+				continue
+			}
+
+			if refreshFailing {
+				notify(fmt.Sprintf("refreshing service %s works again", service))
+
+				refreshFailing = false
+			}
+
+			if taskDefArn == serviceTaskDefArn {
+				continue
+			}
+
+			newTarget, err := resolveLiveTailTarget(ctx, clients.ECS, taskDefArn, partition, *identity.Account, clients.Region)
+			if err != nil {
+				if unresolvedArn != taskDefArn {
+					notify(fmt.Sprintf("service %s moved to %s but its logs cannot be followed yet, retrying: %v", service, taskDefArn, err))
+				}
+
+				unresolvedArn = taskDefArn
+
+				continue
+			}
+
+			// Only a resolved revision counts as followed, so a failure above
+			// is retried on the next tick
+			serviceTaskDefArn = taskDefArn
+			unresolvedArn = ""
+
+			if newTarget == target {
+				continue
+			}
+
+			stopSession()
+			<-done
+
+			sessionCtx, stopSession = context.WithCancel(ctx)
+
+			done, err = runLiveTail(sessionCtx, clients.CloudWatchLogs, []string{newTarget.LogGroupArn}, []string{newTarget.LogStreamPrefix}, logs, notices)
+			if err != nil {
+				done = nil
+
+				notify(fmt.Sprintf("failed to restart live tail for %s: %v", taskDefArn, err))
+
+				return
+			}
+
+			target = newTarget
+
+			notify(fmt.Sprintf("service %s moved to %s, now following %s", service, taskDefArn, target.LogStreamPrefix))
+		}
+	}()
 
-	return TailLogGroups(ctx, clients.CloudWatchLogs, []string{logGroupArn}, []string{logStreamPrefixPattern})
+	return &LiveTail{Logs: logs, Notices: notices, cancel: cancel}, nil
 }
@@ -0,0 +1,95 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// ListServiceNames returns every service in the region as cluster/service.
+// The list is cached on disk per account and region for a few minutes so
+// interactive lookups and shell completion stay fast.
+func ListServiceNames(ctx context.Context, clients *AWSClients) ([]string, error) {
+	return cached(ctx, clients, "services", serviceListCacheTTL, func() ([]string, error) {
+		clusters, err := listClusters(ctx, clients)
+		if err != nil {
+			return nil, err
+		}
+
+		services := []string{}
+
+		for _, cluster := range clusters {
+			for _, service := range cluster.Services {
+				services = append(services, service.ClusterName+"/"+service.Name)
+			}
+		}
+
+		return services, nil
+	})
+}
+
+// ListRevisionNumbers returns the active revision numbers of the service's
+// task definition family, newest first. The numbers are cached on disk for a
+// minute.
+func ListRevisionNumbers(ctx context.Context, clients *AWSClients, cluster, service string) ([]string, error) {
+	return cached(ctx, clients, "revisions-"+cluster+"-"+service, revisionListCacheTTL, func() ([]string, error) {
+		family, err := getFamilyPrefix(ctx, cluster, service, clients.ECS)
+		if err != nil {
+			return nil, err
+		}
+
+		arns, err := listTaskDefinitionArns(ctx, family, types.TaskDefinitionStatusActive, clients.ECS)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions := make([]string, 0, len(arns))
+
+		for _, arn := range arns {
+			_, revision, ok := strings.Cut(taskDefinitionRevision(arn), ":")
+			if _, err := strconv.Atoi(revision); ok && err == nil {
+				revisions = append(revisions, revision)
+			}
+		}
+
+		return revisions, nil
+	})
+}
+
+// ListTaskIDs returns the IDs of the service's running tasks, oldest first.
+// The IDs are cached on disk for a few seconds.
+func ListTaskIDs(ctx context.Context, clients *AWSClients, cluster, service string) ([]string, error) {
+	return cached(ctx, clients, "tasks-"+cluster+"-"+service, taskListCacheTTL, func() ([]string, error) {
+		tasks, err := getTaskDetails(ctx, clients.ECS, cluster, service)
+		if err != nil {
+			return nil, err
+		}
+
+		slices.SortFunc(tasks, func(a, b TaskInfo) int {
+			return a.StartedAt.Compare(b.StartedAt)
+		})
+
+		ids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+
+		return ids, nil
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// ListClusterNames returns the names of all clusters in the region
+func ListClusterNames(ctx context.Context, clients *AWSClients) ([]string, error) {
+	clusterArns, err := getClusterArns(ctx, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(clusterArns))
+
+	for _, clusterArn := range clusterArns {
+		name, err := extractARNResource(clusterArn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract cluster name from ARN %s: %w", clusterArn, err)
+		}
+
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// describeServices describes the services of a cluster
+func describeServices(ctx context.Context, cluster string, serviceArns []string, svc *ecs.Client) ([]types.Service, error) {
+	var services []types.Service
+
+	// DescribeServices accepts at most 10 services per call
+	for batch := range slices.Chunk(serviceArns, 10) {
+		response, err := svc.DescribeServices(ctx, &ecs.DescribeServicesInput{
+			Cluster:  &cluster,
+			Services: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe services in cluster %s: %w", cluster, err)
+		}
+
+		services = append(services, response.Services...)
+	}
+
+	return services, nil
+}
+
+// primaryRolloutState returns the rollout state of the primary deployment
+func primaryRolloutState(deployments []types.Deployment) string {
+	for _, deployment := range deployments {
+		if deref(deployment.Status) == "PRIMARY" {
+			return string(deployment.RolloutState)
+		}
+	}
+
+	return ""
+}
+
+// GetClusterOverview returns the services of a cluster with their counts and
+// running tasks. Tasks are listed once for the whole cluster, so refreshing
+// the overview costs a few calls however many services the cluster runs.
+func GetClusterOverview(ctx context.Context, clients *AWSClients, cluster string) (*ClusterOverview, error) {
+	serviceArns, err := getServiceArns(ctx, clients.ECS, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services for cluster %s: %w", cluster, err)
+	}
+
+	services, err := describeServices(ctx, cluster, serviceArns, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := describeServiceTasks(ctx, cluster, "", types.DesiredStatusRunning, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tasks started by a service belong to the group service:<name>
+	tasksByService := map[string][]TaskStatus{}
+
+	for _, task := range tasks {
+		service, ok := strings.CutPrefix(deref(task.Group), "service:")
+		if ok {
+			tasksByService[service] = append(tasksByService[service], taskStatus(task))
+		}
+	}
+
+	overview := &ClusterOverview{Cluster: cluster, Services: []ServiceOverview{}}
+
+	for _, service := range services {
+		name := deref(service.ServiceName)
+
+		serviceTasks := tasksByService[name]
+		slices.SortFunc(serviceTasks, func(a, b TaskStatus) int {
+			return a.StartedAt.Compare(b.StartedAt)
+		})
+
+		overview.Services = append(overview.Services, ServiceOverview{
+			Cluster:        cluster,
+			Service:        name,
+			Status:         deref(service.Status),
+			TaskDefinition: taskDefinitionRevision(deref(service.TaskDefinition)),
+			DesiredCount:   service.DesiredCount,
+			RunningCount:   service.RunningCount,
+			PendingCount:   service.PendingCount,
+			RolloutState:   primaryRolloutState(service.Deployments),
+			Tasks:          serviceTasks,
+		})
+	}
+
+	slices.SortFunc(overview.Services, func(a, b ServiceOverview) int {
+		return strings.Compare(a.Service, b.Service)
+	})
+
+	return overview, nil
+}
@@ -16,173 +16,638 @@ package ecs
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 )
 
-func deregisterTaskFamily(ctx context.Context, family string, keepLast int, keepDays int, dryRun bool, svc *ecs.Client) (int, int, int, []TaskDefinitionPruneEntry, error) {
-	definitionInput := &ecs.ListTaskDefinitionsInput{
-		FamilyPrefix: &family,
-		Sort:         types.SortOrderDesc,
-	}
+const (
+	// pruneConcurrency is the number of families pruned at the same time
+	pruneConcurrency = 4
+	// pruneRequestsPerSecond caps the ECS calls of all prune workers together
+	pruneRequestsPerSecond = 10
+)
 
-	today := time.Now().UTC()
-	totalCount := 0
-	deleted := 0
-	keep := 0
+// getRunningTaskDefinitions maps the task definitions of all running and
+// pending tasks in the cluster, including one-off tasks, to the ID of one of
+// those tasks.
+func getRunningTaskDefinitions(ctx context.Context, cluster string, svc *ecs.Client) (map[string]string, error) {
+	input := &ecs.ListTasksInput{
+		Cluster:       &cluster,
+		DesiredStatus: types.DesiredStatusRunning,
+	}
 
-	var processedTasks []TaskDefinitionPruneEntry
+	var taskArns []string
 
 	for {
-		resp, err := svc.ListTaskDefinitions(ctx, definitionInput)
+		response, err := svc.ListTasks(ctx, input)
 		if err != nil {
-			return 0, 0, 0, nil, fmt.Errorf("loading the list of definitions failed: %w", err)
+			return nil, fmt.Errorf("failed to list tasks in cluster %s: %w", cluster, err)
 		}
 
-		count := len(resp.TaskDefinitionArns)
-		totalCount += count
+		taskArns = append(taskArns, response.TaskArns...)
 
-		for _, def := range resp.TaskDefinitionArns {
-			response, err := svc.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
-				TaskDefinition: &def,
-			})
+		if response.NextToken == nil {
+			break
+		}
 
-			if err != nil {
-				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-					Arn:     def,
-					DaysOld: -1,
-					Action:  "skipped",
-					Reason:  fmt.Sprintf("Failed to describe: %v", err),
-					Family:  family,
-				})
+		input.NextToken = response.NextToken
+	}
 
+	running := map[string]string{}
+
+	// DescribeTasks accepts at most 100 tasks per call
+	for batch := range slices.Chunk(taskArns, 100) {
+		response, err := svc.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+			Cluster: &cluster,
+			Tasks:   batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tasks in cluster %s: %w", cluster, err)
+		}
+
+		for _, task := range response.Tasks {
+			if task.TaskDefinitionArn == nil || task.TaskArn == nil {
 				continue
 			}
 
-			if response.TaskDefinition.RegisteredAt == nil {
-				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-					Arn:     def,
-					DaysOld: -1,
-					Action:  "skipped",
-					Reason:  "Missing registration date",
-					Family:  family,
-				})
-
+			if _, ok := running[*task.TaskDefinitionArn]; ok {
 				continue
 			}
 
-			diffInDays := int(today.Sub(*response.TaskDefinition.RegisteredAt).Hours() / 24)
+			taskID, err := extractARNResource(*task.TaskArn)
+			if err != nil {
+				taskID = *task.TaskArn
+			}
 
-			// Last X
-			if keep < keepLast {
-				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-					Arn:     def,
-					DaysOld: diffInDays,
-					Action:  "kept",
-					Reason:  fmt.Sprintf("keeping last %d definitions", keepLast),
-					Family:  family,
-				})
-				keep++
+			running[*task.TaskDefinitionArn] = taskID
+		}
+	}
+
+	return running, nil
+}
+
+// getInUseTaskDefinitions maps every task definition referenced by a service
+// deployment (primary or not) or by a running task in the given clusters, or
+// started by an EventBridge rule or schedule, to the reason it must be kept.
+// The warnings name the sources that could not be checked.
+func getInUseTaskDefinitions(ctx context.Context, clusters []string, clients *AWSClients, limiter *rateLimiter) (map[string]string, []string, error) {
+	svc := clients.ECS
+
+	inUse, warnings := getScheduledTaskDefinitions(ctx, clients, limiter)
 
+	for _, cluster := range clusters {
+		clusterName, err := extractARNResource(cluster)
+		if err != nil {
+			clusterName = cluster
+		}
+
+		deployments, err := getServiceDeployments(ctx, cluster, svc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for taskDefinitionArn, revisionDeployments := range deployments {
+			if _, ok := inUse[taskDefinitionArn]; ok {
 				continue
 			}
 
-			if diffInDays < keepDays {
-				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-					Arn:     def,
-					DaysOld: diffInDays,
-					Action:  "kept",
-					Reason:  fmt.Sprintf("newer than %d days", keepDays),
-					Family:  family,
-				})
+			deployment := revisionDeployments[0]
+			inUse[taskDefinitionArn] = fmt.Sprintf("in use by %s deployment of service %s/%s",
+				strings.ToLower(deployment.Status), clusterName, deployment.Service)
+		}
 
+		running, err := getRunningTaskDefinitions(ctx, cluster, svc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for taskDefinitionArn, taskID := range running {
+			if _, ok := inUse[taskDefinitionArn]; ok {
 				continue
 			}
 
-			deleted++
+			inUse[taskDefinitionArn] = fmt.Sprintf("in use by running task %s/%s", clusterName, taskID)
+		}
+	}
+
+	return inUse, warnings, nil
+}
+
+// pruneProgress counts the task definitions described by all prune workers
+// and reports them to PruneOptions.OnProgress.
+type pruneProgress struct {
+	mu       sync.Mutex
+	done     int
+	total    int
+	callback func(done, total int)
+}
+
+func (p *pruneProgress) addTotal(count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-			if !dryRun {
+	p.total += count
+	p.report()
+}
+
+func (p *pruneProgress) advance() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.report()
+}
+
+func (p *pruneProgress) report() {
+	if p.callback != nil {
+		p.callback(p.done, p.total)
+	}
+}
+
+// pruneState is shared by the workers of a single prune
+type pruneState struct {
+	// inUse maps revisions that must never be deregistered to the reason
+	inUse map[string]string
+	// deployed maps revisions recently deployed as primary to the reason
+	deployed map[string]string
+	limiter  *rateLimiter
+	progress *pruneProgress
+}
+
+func deregisterTaskFamily(ctx context.Context, family string, opts PruneOptions, state *pruneState, svc *ecs.Client) (int, int, int, []TaskDefinitionPruneEntry, error) {
+	limiter, progress := state.limiter, state.progress
+
+	err := limiter.Wait(ctx)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	arns, err := listTaskDefinitionArns(ctx, family, types.TaskDefinitionStatusActive, svc)
+	if err != nil {
+		return 0, 0, 0, nil, fmt.Errorf("loading the list of definitions failed: %w", err)
+	}
+
+	progress.addTotal(len(arns))
+
+	today := time.Now().UTC()
+	totalCount := len(arns)
+	deleted := 0
+	kept := 0
+	rules := newPruneRules(opts, state.deployed)
+
+	var processedTasks []TaskDefinitionPruneEntry
+
+	// Revisions are described concurrently up front; the policy below relies
+	// on walking them newest first.
+	for _, described := range describeTaskDefinitions(ctx, arns, svc, limiter, progress.advance) {
+		def := described.Arn
+
+		if described.Err != nil {
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: -1,
+				Action:  "skipped",
+				Reason:  fmt.Sprintf("Failed to describe: %v", described.Err),
+				Family:  family,
+			})
+
+			continue
+		}
+
+		if described.TaskDefinition.RegisteredAt == nil {
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: -1,
+				Action:  "skipped",
+				Reason:  "Missing registration date",
+				Family:  family,
+			})
+
+			continue
+		}
+
+		diffInDays := int(today.Sub(*described.TaskDefinition.RegisteredAt).Hours() / 24)
+
+		// Never deregister a revision that something still runs. It is not
+		// seen by the retention rules so they keep as many revisions to roll
+		// back to as requested.
+		if reason, ok := state.inUse[def]; ok {
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: diffInDays,
+				Action:  "kept",
+				Reason:  reason,
+				Family:  family,
+			})
+			kept++
+
+			continue
+		}
+
+		reason, keep := applyPruneRules(rules, described.TaskDefinition, diffInDays)
+		if keep {
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: diffInDays,
+				Action:  "kept",
+				Reason:  reason,
+				Family:  family,
+			})
+			kept++
+
+			continue
+		}
+
+		deleted++
+
+		if !opts.DryRun {
+			err := limiter.Wait(ctx)
+			if err != nil {
+				return 0, 0, 0, nil, err
+			}
+
+			err = retryThrottled(ctx, func() error {
 				_, err := svc.DeregisterTaskDefinition(ctx, &ecs.DeregisterTaskDefinitionInput{TaskDefinition: &def})
-				if err != nil {
-					processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-						Arn:     def,
-						DaysOld: diffInDays,
-						Action:  "skipped",
-						Reason:  fmt.Sprintf("deregistration failed: %v", err),
-						Family:  family,
-					})
-					deleted-- // Decrement since it wasn't actually deleted
-
-					continue
-				}
 
+				return err
+			})
+			if err != nil {
 				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
 					Arn:     def,
 					DaysOld: diffInDays,
-					Action:  "deleted",
-					Reason:  "deregistered successfully",
+					Action:  "skipped",
+					Reason:  fmt.Sprintf("deregistration failed: %v", err),
 					Family:  family,
 				})
-			} else {
-				processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
-					Arn:     def,
-					DaysOld: diffInDays,
-					Action:  "deleted",
+				deleted-- // Decrement since it wasn't actually deleted
+
+				continue
+			}
+
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: diffInDays,
+				Action:  "deleted",
+				Reason:  "deregistered successfully",
+				Family:  family,
+			})
+		} else {
+			processedTasks = append(processedTasks, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: diffInDays,
+				Action:  "deleted",
+				Reason:  "dry run - would be deleted",
+				Family:  family,
+			})
+		}
+	}
+
+	skipped := totalCount - deleted - kept
+
+	return totalCount, deleted, skipped, processedTasks, nil
+}
+
+// deleteInactiveTaskFamily permanently deletes the INACTIVE revisions of the
+// family that the policy does not keep, together with the revisions
+// deregistered (or, in a dry run, to be deregistered) by this prune in
+// processed. Revisions still in use and revisions kept by a retention rule
+// stay; the latter are reported with the rule's reason.
+func deleteInactiveTaskFamily(ctx context.Context, family string, opts PruneOptions, state *pruneState, processed []TaskDefinitionPruneEntry, svc *ecs.Client) (int, []TaskDefinitionPruneEntry, []PruneDeleteFailure, error) {
+	limiter, progress := state.limiter, state.progress
+
+	today := time.Now().UTC()
+
+	var candidates []TaskDefinitionPruneEntry
+
+	for _, entry := range processed {
+		if entry.Action == "deleted" {
+			candidates = append(candidates, entry)
+		}
+	}
+
+	err := limiter.Wait(ctx)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	inactiveArns, err := listTaskDefinitionArns(ctx, family, types.TaskDefinitionStatusInactive, svc)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var failures []PruneDeleteFailure
+	var entries []TaskDefinitionPruneEntry
+	var unknown []string
+
+	for _, def := range inactiveArns {
+		if _, ok := state.inUse[def]; ok {
+			continue
+		}
+
+		if slices.ContainsFunc(candidates, func(entry TaskDefinitionPruneEntry) bool { return entry.Arn == def }) {
+			continue
+		}
+
+		unknown = append(unknown, def)
+	}
+
+	progress.addTotal(len(unknown))
+
+	// The revisions were listed newest first, which rules such as keep-last
+	// rely on
+	rules := newPruneRules(opts, state.deployed)
+
+	for _, described := range describeTaskDefinitions(ctx, unknown, svc, limiter, progress.advance) {
+		if described.Err != nil {
+			failures = append(failures, PruneDeleteFailure{Arn: described.Arn, Family: family, Reason: described.Err.Error()})
+
+			continue
+		}
+
+		if described.TaskDefinition.RegisteredAt == nil {
+			continue
+		}
+
+		diffInDays := int(today.Sub(*described.TaskDefinition.RegisteredAt).Hours() / 24)
+
+		if reason, keep := applyPruneRules(rules, described.TaskDefinition, diffInDays); keep {
+			entries = append(entries, TaskDefinitionPruneEntry{
+				Arn:     described.Arn,
+				DaysOld: diffInDays,
+				Action:  "kept-inactive",
+				Reason:  reason,
+				Family:  family,
+			})
+
+			continue
+		}
+
+		candidates = append(candidates, TaskDefinitionPruneEntry{Arn: described.Arn, DaysOld: diffInDays, Family: family})
+	}
+
+	deleted := 0
+
+	// DeleteTaskDefinitions accepts at most 10 task definitions per call
+	for batch := range slices.Chunk(candidates, 10) {
+		if opts.DryRun {
+			for _, candidate := range batch {
+				entries = append(entries, TaskDefinitionPruneEntry{
+					Arn:     candidate.Arn,
+					DaysOld: candidate.DaysOld,
+					Action:  "deleted-inactive",
 					Reason:  "dry run - would be deleted",
 					Family:  family,
 				})
 			}
+
+			deleted += len(batch)
+
+			continue
 		}
 
-		if resp.NextToken == nil {
-			break
+		arns := make([]string, len(batch))
+		daysOld := make(map[string]int, len(batch))
+
+		for i, candidate := range batch {
+			arns[i] = candidate.Arn
+			daysOld[candidate.Arn] = candidate.DaysOld
+		}
+
+		err := limiter.Wait(ctx)
+		if err != nil {
+			return 0, nil, nil, err
 		}
 
-		definitionInput.NextToken = resp.NextToken
+		var response *ecs.DeleteTaskDefinitionsOutput
+
+		err = retryThrottled(ctx, func() error {
+			response, err = svc.DeleteTaskDefinitions(ctx, &ecs.DeleteTaskDefinitionsInput{TaskDefinitions: arns})
+
+			return err
+		})
+		if err != nil {
+			for _, def := range arns {
+				failures = append(failures, PruneDeleteFailure{
+					Arn:    def,
+					Family: family,
+					Reason: fmt.Sprintf("failed to delete task definitions: %v", err),
+				})
+			}
+
+			continue
+		}
+
+		for _, taskDef := range response.TaskDefinitions {
+			def := deref(taskDef.TaskDefinitionArn)
+
+			entries = append(entries, TaskDefinitionPruneEntry{
+				Arn:     def,
+				DaysOld: daysOld[def],
+				Action:  "deleted-inactive",
+				Reason:  "deleted successfully",
+				Family:  family,
+			})
+			deleted++
+		}
+
+		for _, failure := range response.Failures {
+			reason := deref(failure.Reason)
+			if failure.Detail != nil {
+				reason += ": " + *failure.Detail
+			}
+
+			failures = append(failures, PruneDeleteFailure{Arn: deref(failure.Arn), Family: family, Reason: reason})
+		}
 	}
 
-	skipped := totalCount - deleted - keep
+	return deleted, entries, failures, nil
+}
 
-	return totalCount, deleted, skipped, processedTasks, nil
+// getDeployedWithin collects the revisions for the keep-deployed-within rule
+// when it is enabled
+func getDeployedWithin(ctx context.Context, clusters []string, opts PruneOptions, limiter *rateLimiter, svc *ecs.Client) (map[string]string, error) {
+	if opts.KeepDeployedWithin <= 0 {
+		return nil, nil
+	}
+
+	deployed, err := getRecentlyDeployedTaskDefinitions(ctx, clusters, time.Now().Add(-opts.KeepDeployedWithin), limiter, svc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect recently deployed task definitions: %w", err)
+	}
+
+	return deployed, nil
+}
+
+// pruneFamilies applies the retention policy to every family, a few families
+// at a time with the ECS calls of all workers rate limited. A family that
+// fails is reported in its summary and does not stop the others.
+func pruneFamilies(ctx context.Context, families []string, opts PruneOptions, inUse, deployed map[string]string, limiter *rateLimiter, svc *ecs.Client) ([]PruneFamilySummary, [][]TaskDefinitionPruneEntry, [][]PruneDeleteFailure) {
+	summaries := make([]PruneFamilySummary, len(families))
+	entries := make([][]TaskDefinitionPruneEntry, len(families))
+	failures := make([][]PruneDeleteFailure, len(families))
+
+	state := &pruneState{
+		inUse:    inUse,
+		deployed: deployed,
+		limiter:  limiter,
+		progress: &pruneProgress{callback: opts.OnProgress},
+	}
+
+	semaphore := make(chan struct{}, pruneConcurrency)
+
+	var wg sync.WaitGroup
+
+	for i, family := range families {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			total, deleted, skipped, processed, err := deregisterTaskFamily(ctx, family, opts, state, svc)
+			summaries[i] = PruneFamilySummary{
+				Family:       family,
+				TotalCount:   total,
+				DeletedCount: deleted,
+				KeptCount:    total - deleted - skipped,
+				SkippedCount: skipped,
+				Err:          err,
+			}
+			entries[i] = processed
+
+			if err != nil || !opts.DeleteInactive {
+				return
+			}
+
+			inactiveDeleted, inactiveEntries, deleteFailures, err := deleteInactiveTaskFamily(ctx, family, opts, state, processed, svc)
+			summaries[i].InactiveDeletedCount = inactiveDeleted
+			summaries[i].Err = err
+			entries[i] = append(entries[i], inactiveEntries...)
+			failures[i] = deleteFailures
+		}()
+	}
+
+	wg.Wait()
+
+	return summaries, entries, failures
+}
+
+// pruneResult adds up the per-family outcome of pruneFamilies
+func pruneResult(families []string, summaries []PruneFamilySummary, entries [][]TaskDefinitionPruneEntry, failures [][]PruneDeleteFailure, opts PruneOptions) *PruneResult {
+	result := &PruneResult{
+		Families:        families,
+		FamilySummaries: summaries,
+		DryRun:          opts.DryRun,
+		ProcessedTasks:  []TaskDefinitionPruneEntry{},
+	}
+
+	for i, summary := range summaries {
+		result.TotalCount += summary.TotalCount
+		result.DeletedCount += summary.DeletedCount
+		result.KeptCount += summary.KeptCount
+		result.SkippedCount += summary.SkippedCount
+		result.InactiveDeletedCount += summary.InactiveDeletedCount
+		result.ProcessedTasks = append(result.ProcessedTasks, entries[i]...)
+		result.DeleteFailures = append(result.DeleteFailures, failures[i]...)
+	}
+
+	return result
+}
+
+// withClusters returns clusters followed by the clusters in opts that are
+// not already listed.
+func withClusters(clusters []string, opts PruneOptions) []string {
+	for _, other := range opts.AlsoCheckClusters {
+		if !slices.Contains(clusters, other) {
+			clusters = append(clusters, other)
+		}
+	}
+
+	return clusters
 }
 
-func Prune(ctx context.Context, clients *AWSClients, cluster, service string, keepLast int, keepDays int, dryRun bool) (*PruneResult, error) {
-	familyPrefix, err := getFamilyPrefix(ctx, cluster, service, clients.ECS)
+func Prune(ctx context.Context, clients *AWSClients, cluster, service string, opts PruneOptions) (*PruneResult, error) {
+	family, err := getFamilyPrefix(ctx, cluster, service, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the service's own family; families that merely share its name as
+	// a prefix (web-worker for web) belong to other services.
+	families := []string{family}
+
+	clusters := withClusters([]string{cluster}, opts)
+
+	limiter := newRateLimiter(pruneRequestsPerSecond)
+	defer limiter.Stop()
+
+	inUse, warnings, err := getInUseTaskDefinitions(ctx, clusters, clients, limiter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect task definitions in use: %w", err)
+	}
+
+	deployed, err := getDeployedWithin(ctx, clusters, opts, limiter, clients.ECS)
 	if err != nil {
 		return nil, err
 	}
 
+	summaries, entries, failures := pruneFamilies(ctx, families, opts, inUse, deployed, limiter, clients.ECS)
+
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			return nil, summary.Err
+		}
+	}
+
+	result := pruneResult(families, summaries, entries, failures, opts)
+	result.CheckedClusters = clusters
+	result.Warnings = warnings
+
+	return result, nil
+}
+
+// PruneAllFamilies applies the retention policy to every active task
+// definition family in the account, or to those starting with familyPrefix.
+// Revisions in use by any service or running task in any cluster of the
+// account are kept.
+func PruneAllFamilies(ctx context.Context, clients *AWSClients, familyPrefix string, opts PruneOptions) (*PruneResult, error) {
 	families, err := getFamilies(ctx, familyPrefix, clients.ECS)
 	if err != nil {
 		return nil, err
 	}
 
-	result := &PruneResult{
-		Families:       families,
-		TotalCount:     0,
-		DeletedCount:   0,
-		KeptCount:      0,
-		SkippedCount:   0,
-		DryRun:         dryRun,
-		ProcessedTasks: []TaskDefinitionPruneEntry{},
+	clusters, err := getClusterArns(ctx, clients.ECS)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, family := range families {
-		totalCount, deletedCount, skippedCount, processedTasks, err := deregisterTaskFamily(ctx, family, keepLast, keepDays, dryRun, clients.ECS)
-		if err != nil {
-			return nil, err
-		}
+	clusters = withClusters(clusters, opts)
 
-		result.TotalCount += totalCount
-		result.DeletedCount += deletedCount
-		result.SkippedCount += skippedCount
-		result.ProcessedTasks = append(result.ProcessedTasks, processedTasks...)
+	limiter := newRateLimiter(pruneRequestsPerSecond)
+	defer limiter.Stop()
+
+	inUse, warnings, err := getInUseTaskDefinitions(ctx, clusters, clients, limiter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect task definitions in use: %w", err)
 	}
 
-	// Calculate kept count
-	result.KeptCount = result.TotalCount - result.DeletedCount - result.SkippedCount
+	deployed, err := getDeployedWithin(ctx, clusters, opts, limiter, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, entries, failures := pruneFamilies(ctx, families, opts, inUse, deployed, limiter, clients.ECS)
+
+	result := pruneResult(families, summaries, entries, failures, opts)
+	result.CheckedClusters = clusters
+	result.Warnings = warnings
 
 	return result, nil
 }
@@ -0,0 +1,310 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"runecs.io/v1/internal/utils"
+)
+
+// pruneRule is a single retention rule. Rules see every revision of a family
+// newest first, so stateful rules can count; Keep returns the reason when the
+// rule keeps the revision.
+type pruneRule interface {
+	Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool)
+}
+
+// keepLastRule keeps the newest revisions of the family
+type keepLastRule struct {
+	count int
+	seen  int
+}
+
+func (r *keepLastRule) Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	r.seen++
+
+	if r.seen > r.count {
+		return "", false
+	}
+
+	return fmt.Sprintf("keep-last: one of the last %d revisions", r.count), true
+}
+
+// keepDaysRule keeps revisions registered in the last days
+type keepDaysRule struct {
+	days int
+}
+
+func (r *keepDaysRule) Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	if daysOld >= r.days {
+		return "", false
+	}
+
+	return fmt.Sprintf("keep-days: newer than %d days", r.days), true
+}
+
+// keepDeployedWithinRule keeps revisions that were the primary deployment of
+// a service recently
+type keepDeployedWithinRule struct {
+	deployed map[string]string
+}
+
+func (r *keepDeployedWithinRule) Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	reason, ok := r.deployed[deref(taskDef.TaskDefinitionArn)]
+	if !ok {
+		return "", false
+	}
+
+	return "keep-deployed-within: " + reason, true
+}
+
+// keepTagPatternRule keeps revisions with an image tag matching one of the
+// glob patterns
+type keepTagPatternRule struct {
+	patterns []string
+}
+
+func (r *keepTagPatternRule) Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	for _, container := range taskDef.ContainerDefinitions {
+		image, err := ParseImageReference(deref(container.Image))
+		if err != nil || image.Tag == "" {
+			continue
+		}
+
+		for _, pattern := range r.patterns {
+			if matched, _ := path.Match(pattern, image.Tag); matched {
+				return fmt.Sprintf("keep-tag-pattern: image tag %s matches %s", image.Tag, pattern), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// keepPerImageRule keeps the newest revisions of every distinct set of
+// container images
+type keepPerImageRule struct {
+	count int
+	seen  map[string]int
+}
+
+func (r *keepPerImageRule) Keep(taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	images := make([]string, 0, len(taskDef.ContainerDefinitions))
+	for _, container := range taskDef.ContainerDefinitions {
+		images = append(images, deref(container.Image))
+	}
+
+	key := strings.Join(images, ", ")
+
+	r.seen[key]++
+
+	if r.seen[key] > r.count {
+		return "", false
+	}
+
+	return fmt.Sprintf("keep-per-image: one of the last %d revisions with image %s", r.count, key), true
+}
+
+// newPruneRules builds the retention rules enabled in opts for a single family.
+// The order decides which rule is named when several keep a revision.
+func newPruneRules(opts PruneOptions, deployed map[string]string) []pruneRule {
+	var rules []pruneRule
+
+	if opts.KeepLast > 0 {
+		rules = append(rules, &keepLastRule{count: opts.KeepLast})
+	}
+
+	if opts.KeepDays > 0 {
+		rules = append(rules, &keepDaysRule{days: opts.KeepDays})
+	}
+
+	if opts.KeepDeployedWithin > 0 {
+		rules = append(rules, &keepDeployedWithinRule{deployed: deployed})
+	}
+
+	if len(opts.KeepTagPatterns) > 0 {
+		rules = append(rules, &keepTagPatternRule{patterns: opts.KeepTagPatterns})
+	}
+
+	if opts.KeepPerImage > 0 {
+		rules = append(rules, &keepPerImageRule{count: opts.KeepPerImage, seen: map[string]int{}})
+	}
+
+	return rules
+}
+
+// applyPruneRules evaluates every rule, so stateful rules count the revision,
+// and returns the reason of the first rule that keeps it.
+func applyPruneRules(rules []pruneRule, taskDef *types.TaskDefinition, daysOld int) (string, bool) {
+	reason := ""
+	kept := false
+
+	for _, rule := range rules {
+		ruleReason, ok := rule.Keep(taskDef, daysOld)
+		if ok && !kept {
+			reason = ruleReason
+			kept = true
+		}
+	}
+
+	return reason, kept
+}
+
+// ValidateTagPatterns checks that the --keep-tag-pattern globs are well formed
+func ValidateTagPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// listServiceDeployments lists every deployment of a service, oldest first.
+// A deployment becomes the service's primary as soon as it starts, so ones
+// that were later stopped or rolled back count as well.
+func listServiceDeployments(ctx context.Context, cluster, service string, limiter *rateLimiter, svc *ecs.Client) ([]types.ServiceDeploymentBrief, error) {
+	input := &ecs.ListServiceDeploymentsInput{
+		Cluster: &cluster,
+		Service: &service,
+	}
+
+	var deployments []types.ServiceDeploymentBrief
+
+	for {
+		var response *ecs.ListServiceDeploymentsOutput
+
+		err := limitedCall(ctx, limiter, func() error {
+			var err error
+
+			response, err = svc.ListServiceDeployments(ctx, input)
+
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments of service %s: %w", service, err)
+		}
+
+		for _, deployment := range response.ServiceDeployments {
+			if deployment.CreatedAt != nil {
+				deployments = append(deployments, deployment)
+			}
+		}
+
+		if response.NextToken == nil {
+			break
+		}
+
+		input.NextToken = response.NextToken
+	}
+
+	slices.SortFunc(deployments, func(a, b types.ServiceDeploymentBrief) int {
+		return a.CreatedAt.Compare(*b.CreatedAt)
+	})
+
+	return deployments, nil
+}
+
+// getRecentlyDeployedTaskDefinitions maps every task definition that was the
+// primary deployment of a service in the given clusters at some point since
+// the given time to a reason naming the service. A revision stays primary
+// until the next deployment starts, so one deployed before since still
+// counts when it was replaced after since. ECS records service deployments
+// only for services using the rolling update deployment controller. Every
+// call waits on limiter.
+func getRecentlyDeployedTaskDefinitions(ctx context.Context, clusters []string, since time.Time, limiter *rateLimiter, svc *ecs.Client) (map[string]string, error) {
+	// service revision ARN -> service name
+	primaries := map[string]string{}
+
+	for _, cluster := range clusters {
+		var serviceArns []string
+
+		err := limitedCall(ctx, limiter, func() error {
+			var err error
+
+			serviceArns, err = getServiceArns(ctx, svc, cluster)
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, serviceArn := range serviceArns {
+			deployments, err := listServiceDeployments(ctx, cluster, serviceArn, limiter, svc)
+			if err != nil {
+				return nil, err
+			}
+
+			serviceName, err := extractARNResource(serviceArn)
+			if err != nil {
+				serviceName = serviceArn
+			}
+
+			for i, deployment := range deployments {
+				if deployment.TargetServiceRevisionArn == nil {
+					continue
+				}
+
+				replaced := i+1 < len(deployments)
+				if replaced && deployments[i+1].CreatedAt.Before(since) {
+					continue
+				}
+
+				primaries[*deployment.TargetServiceRevisionArn] = serviceName
+			}
+		}
+	}
+
+	deployed := map[string]string{}
+
+	// DescribeServiceRevisions accepts at most 20 revisions per call
+	for batch := range slices.Chunk(utils.SortedKeys(primaries), 20) {
+		var response *ecs.DescribeServiceRevisionsOutput
+
+		err := limitedCall(ctx, limiter, func() error {
+			var err error
+
+			response, err = svc.DescribeServiceRevisions(ctx, &ecs.DescribeServiceRevisionsInput{
+				ServiceRevisionArns: batch,
+			})
+
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe service revisions: %w", err)
+		}
+
+		for _, revision := range response.ServiceRevisions {
+			if revision.TaskDefinition == nil {
+				continue
+			}
+
+			deployed[*revision.TaskDefinition] = fmt.Sprintf("primary deployment of service %s after %s",
+				primaries[deref(revision.ServiceRevisionArn)], since.Local().Format(time.DateOnly))
+		}
+	}
+
+	return deployed, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// throttleRetries is the number of extra attempts after the SDK's own
+	// retries gave up on a throttled call
+	throttleRetries = 5
+	// throttleBaseDelay is the first backoff delay, doubled on every attempt
+	throttleBaseDelay = 500 * time.Millisecond
+)
+
+// throttlingErrorCodes are the API error codes AWS uses for request throttling
+var throttlingErrorCodes = []string{
+	"ThrottlingException",
+	"Throttling",
+	"TooManyRequestsException",
+	"RequestLimitExceeded",
+}
+
+// rateLimiter spaces out API calls made by concurrent workers so that bulk
+// operations stay below the ECS request quotas. A nil limiter does not wait.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(requestsPerSecond int) *rateLimiter {
+	return &rateLimiter{ticker: time.NewTicker(time.Second / time.Duration(requestsPerSecond))}
+}
+
+// Wait blocks until the next call may be made or the context is cancelled
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) Stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
+
+// isThrottlingError reports whether err is AWS rejecting a request because of
+// the request rate
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	for _, code := range throttlingErrorCodes {
+		if apiErr.ErrorCode() == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitedCall waits on limiter before every attempt of fn and retries fn
+// when AWS throttles it
+func limitedCall(ctx context.Context, limiter *rateLimiter, fn func() error) error {
+	return retryThrottled(ctx, func() error {
+		err := limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+
+		return fn()
+	})
+}
+
+// retryThrottled calls fn until it succeeds, fails with an error other than
+// throttling, or runs out of attempts. Delays grow exponentially with jitter
+// so that concurrent workers do not retry in lockstep.
+func retryThrottled(ctx context.Context, fn func() error) error {
+	delay := throttleBaseDelay
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == throttleRetries || !isThrottlingError(err) {
+			return err
+		}
+
+		jitter := time.Duration(rand.Int64N(int64(delay)))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay + jitter):
+		}
+
+		delay *= 2
+	}
+}
@@ -17,49 +17,415 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 )
 
-func stopAll(ctx context.Context, cluster, service string, client *ecs.Client) ([]StoppedTaskInfo, error) {
-	tasks, err := client.ListTasks(ctx, &ecs.ListTasksInput{
-		Cluster:     &cluster,
-		ServiceName: &service,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list tasks: %w", err)
+const (
+	// defaultRestartHealthTimeout is how long a wave waits for healthy
+	// replacements when RestartOptions.HealthTimeout is not set
+	defaultRestartHealthTimeout = 10 * time.Minute
+	// restartPollInterval is how often the replacements of a wave are checked
+	restartPollInterval = 10 * time.Second
+)
+
+// ErrReplacementFailed means the tasks ECS started in place of the stopped
+// ones stopped themselves or did not become healthy in time.
+var ErrReplacementFailed = errors.New("replacement tasks failed")
+
+// describeServiceTasks returns the tasks of the service with the given
+// desired status, or of the whole cluster when service is empty.
+func describeServiceTasks(ctx context.Context, cluster, service string, desiredStatus types.DesiredStatus, client *ecs.Client) ([]types.Task, error) {
+	input := &ecs.ListTasksInput{
+		Cluster:       &cluster,
+		DesiredStatus: desiredStatus,
+	}
+
+	if service != "" {
+		input.ServiceName = &service
+	}
+
+	var taskArns []string
+
+	for {
+		response, err := client.ListTasks(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+
+		taskArns = append(taskArns, response.TaskArns...)
+
+		if response.NextToken == nil {
+			break
+		}
+
+		input.NextToken = response.NextToken
+	}
+
+	var tasks []types.Task
+
+	// DescribeTasks accepts at most 100 tasks per call
+	for batch := range slices.Chunk(taskArns, 100) {
+		response, err := client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+			Cluster: &cluster,
+			Tasks:   batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tasks: %w", err)
+		}
+
+		tasks = append(tasks, response.Tasks...)
 	}
 
+	return tasks, nil
+}
+
+// defaultStopReason is recorded on tasks stopped by a restart without a
+// reason of its own
+const defaultStopReason = "Restarted by runecs"
+
+// stopTasks stops the tasks and reports the ones ECS refused to stop
+func stopTasks(ctx context.Context, cluster string, taskArns []string, reason string, client *ecs.Client) ([]StoppedTaskInfo, []StopTaskFailure) {
 	var stoppedTasks []StoppedTaskInfo
-	for _, taskArn := range tasks.TaskArns {
+
+	var failures []StopTaskFailure
+
+	for _, taskArn := range taskArns {
 		output, err := client.StopTask(ctx, &ecs.StopTaskInput{
 			Cluster: &cluster,
 			Task:    &taskArn,
+			Reason:  aws.String(reason),
 		})
 		if err != nil {
-			// Continue with other tasks but log this error
+			failures = append(failures, StopTaskFailure{TaskArn: taskArn, Err: err})
+
 			continue
 		}
 
-		if output.Task == nil {
+		if output.Task == nil || output.Task.TaskArn == nil {
+			failures = append(failures, StopTaskFailure{
+				TaskArn: taskArn,
+				Err:     errors.New("invalid stop task response: missing task"),
+			})
+
 			continue
 		}
 
-		if output.Task.TaskArn == nil || output.Task.StartedAt == nil {
+		stopped := StoppedTaskInfo{TaskArn: *output.Task.TaskArn}
+		if output.Task.StartedAt != nil {
+			stopped.StartedAt = *output.Task.StartedAt
+		}
+
+		stoppedTasks = append(stoppedTasks, stopped)
+	}
+
+	return stoppedTasks, failures
+}
+
+// taskPrivateIP returns the private IP of a task using the awsvpc network
+// mode, which is the ID of its load balancer targets.
+func taskPrivateIP(task types.Task) string {
+	for _, attachment := range task.Attachments {
+		if deref(attachment.Type) != "ElasticNetworkInterface" {
 			continue
 		}
 
-		stoppedTasks = append(stoppedTasks, StoppedTaskInfo{
-			TaskArn:   *output.Task.TaskArn,
-			StartedAt: *output.Task.StartedAt,
-		})
+		for _, detail := range attachment.Details {
+			if deref(detail.Name) == "privateIPv4Address" {
+				return deref(detail.Value)
+			}
+		}
+	}
+
+	return ""
+}
+
+// replacementHealth decides whether the replacement tasks of a restart are
+// healthy. A task is healthy once it is RUNNING, its container health checks
+// pass when the task definition defines any, and it is a healthy target of
+// every target group of the service. Tasks without an awsvpc IP address are
+// not matched to targets.
+type replacementHealth struct {
+	cluster, service string
+	clients          *AWSClients
+	healthChecks     map[string]bool
+}
+
+// hasHealthCheck reports whether any container of the task definition has
+// a health check
+func (h *replacementHealth) hasHealthCheck(ctx context.Context, taskDefinitionArn string) (bool, error) {
+	if defined, ok := h.healthChecks[taskDefinitionArn]; ok {
+		return defined, nil
+	}
+
+	taskDef, err := describeTaskDefinitionRevision(ctx, taskDefinitionArn, h.clients.ECS)
+	if err != nil {
+		return false, err
+	}
+
+	defined := slices.ContainsFunc(taskDef.ContainerDefinitions, func(container types.ContainerDefinition) bool {
+		return container.HealthCheck != nil
+	})
+	h.healthChecks[taskDefinitionArn] = defined
+
+	return defined, nil
+}
+
+// targetStates maps every target group of the service to the states of its
+// targets by target ID
+func targetStates(targets []TargetStatus, loadBalancers []types.LoadBalancer) map[string]map[string]string {
+	groups := map[string]map[string]string{}
+
+	for _, loadBalancer := range loadBalancers {
+		if loadBalancer.TargetGroupArn != nil {
+			groups[*loadBalancer.TargetGroupArn] = map[string]string{}
+		}
+	}
+
+	for _, target := range targets {
+		groups[target.TargetGroupArn][target.Target] = target.State
+	}
+
+	return groups
+}
+
+func (h *replacementHealth) healthy(ctx context.Context, task types.Task, targets map[string]map[string]string) (bool, error) {
+	if deref(task.LastStatus) != "RUNNING" {
+		return false, nil
+	}
+
+	hasHealthCheck, err := h.hasHealthCheck(ctx, deref(task.TaskDefinitionArn))
+	if err != nil {
+		return false, err
+	}
+
+	if hasHealthCheck && task.HealthStatus != types.HealthStatusHealthy {
+		return false, nil
+	}
+
+	ip := taskPrivateIP(task)
+	if ip == "" {
+		return true, nil
+	}
+
+	for _, states := range targets {
+		if states[ip] != string(elbtypes.TargetHealthStateEnumHealthy) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// check counts the healthy replacements, tasks not in original, and fails
+// when a replacement started after since has already stopped.
+func (h *replacementHealth) check(ctx context.Context, original map[string]bool, since time.Time) (int, int32, error) {
+	service, err := describeService(ctx, h.cluster, h.service, h.clients.ECS)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stoppedTasks, err := describeServiceTasks(ctx, h.cluster, h.service, types.DesiredStatusStopped, h.clients.ECS)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, task := range stoppedTasks {
+		if original[deref(task.TaskArn)] || task.CreatedAt == nil || task.CreatedAt.Before(since) {
+			continue
+		}
+
+		return 0, 0, fmt.Errorf("%w: task %s stopped: %s", ErrReplacementFailed, taskID(task), deref(task.StoppedReason))
+	}
+
+	runningTasks, err := describeServiceTasks(ctx, h.cluster, h.service, types.DesiredStatusRunning, h.clients.ECS)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	targets, err := getTargetHealth(ctx, service.LoadBalancers, runningTasks, h.clients.ELB)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	states := targetStates(targets, service.LoadBalancers)
+
+	healthy := 0
+
+	for _, task := range runningTasks {
+		if original[deref(task.TaskArn)] {
+			continue
+		}
+
+		ok, err := h.healthy(ctx, task, states)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if ok {
+			healthy++
+		}
+	}
+
+	return healthy, service.DesiredCount, nil
+}
+
+// waitForReplacements waits until the service runs stopped healthy
+// replacements of the original tasks, capped at its desired count.
+func (h *replacementHealth) waitForReplacements(ctx context.Context, original map[string]bool, stopped int, since time.Time, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		healthy, desiredCount, err := h.check(ctx, original, since)
+		if err != nil {
+			return err
+		}
+
+		needed := min(stopped, int(desiredCount))
+		if healthy >= needed {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %d of %d replacement tasks healthy after %s", ErrReplacementFailed, healthy, needed, timeout)
+		}
+
+		err = sleepContext(ctx, restartPollInterval)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// selectTasks returns the tasks matching every selector in opts. Tasks are
+// named by ID or ARN; naming a task the service does not run is an error.
+func selectTasks(tasks []TaskInfo, opts RestartOptions) ([]TaskInfo, error) {
+	for _, id := range opts.TaskIDs {
+		if !slices.ContainsFunc(tasks, func(task TaskInfo) bool { return task.ID == id || task.Arn == id }) {
+			return nil, fmt.Errorf("task %s is not running in the service", id)
+		}
+	}
+
+	return slices.DeleteFunc(tasks, func(task TaskInfo) bool {
+		if len(opts.TaskIDs) > 0 && !slices.Contains(opts.TaskIDs, task.ID) && !slices.Contains(opts.TaskIDs, task.Arn) {
+			return true
+		}
+
+		if opts.OlderThan > 0 && (task.StartedAt.IsZero() || time.Since(task.StartedAt) < opts.OlderThan) {
+			return true
+		}
+
+		return opts.Unhealthy && task.HealthStatus != string(types.HealthStatusUnhealthy)
+	}), nil
+}
+
+// killTasks stops the running tasks of the service selected by opts in waves
+// of opts.BatchSize. After every wave, the last one included, it waits for
+// healthy replacements, and between waves it pauses for opts.Interval. It
+// aborts when the replacements fail.
+func killTasks(ctx context.Context, clients *AWSClients, cluster, service string, opts RestartOptions, result *RestartResult) error {
+	tasks, err := getTaskDetails(ctx, clients.ECS, cluster, service)
+	if err != nil {
+		return err
+	}
+
+	// Tasks running before the restart are never counted as replacements
+	original := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		original[task.Arn] = true
+	}
+
+	selected, err := selectTasks(tasks, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	taskArns := make([]string, 0, len(selected))
+	for _, task := range selected {
+		taskArns = append(taskArns, task.Arn)
+	}
+
+	reason := opts.StopReason
+	if reason == "" {
+		reason = defaultStopReason
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > len(taskArns) {
+		batchSize = max(len(taskArns), 1)
+	}
+
+	waves := slices.Collect(slices.Chunk(taskArns, batchSize))
+	result.Waves = len(waves)
+
+	timeout := opts.HealthTimeout
+	if timeout <= 0 {
+		timeout = defaultRestartHealthTimeout
+	}
+
+	health := &replacementHealth{cluster: cluster, service: service, clients: clients, healthChecks: map[string]bool{}}
+	since := time.Now()
+
+	for i, wave := range waves {
+		stopped, failures := stopTasks(ctx, cluster, wave, reason, clients.ECS)
+		result.StoppedTasks = append(result.StoppedTasks, stopped...)
+		result.StopFailures = append(result.StopFailures, failures...)
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(RestartEvent{Wave: i + 1, Waves: len(waves), Stopped: stopped})
+		}
+
+		// Without --batch-size every task is stopped at once and the service
+		// replaces them on its own
+		if opts.BatchSize <= 0 {
+			break
+		}
+
+		err = health.waitForReplacements(ctx, original, len(result.StoppedTasks), since, timeout)
+		if err != nil {
+			return fmt.Errorf("aborted after wave %d of %d: %w", i+1, len(waves), err)
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(RestartEvent{Wave: i + 1, Waves: len(waves), Healthy: true})
+		}
+
+		if i == len(waves)-1 {
+			break
+		}
+
+		err = sleepContext(ctx, opts.Interval)
+		if err != nil {
+			return err
+		}
 	}
 
-	return stoppedTasks, nil
+	return nil
 }
 
-func forceNewDeploy(ctx context.Context, cluster, service string, client *ecs.Client) (string, string, error) {
-	taskDef, err := latestTaskDefinitionArn(ctx, cluster, service, client)
+func forceNewDeploy(ctx context.Context, cluster, service, revision string, client *ecs.Client) (string, string, error) {
+	taskDef, err := resolveRevision(ctx, cluster, service, revision, client)
 	if err != nil {
 		return "", "", err
 	}
@@ -90,21 +456,24 @@ func forceNewDeploy(ctx context.Context, cluster, service string, client *ecs.Cl
 	return *output.Service.ServiceArn, *output.Service.TaskDefinition, nil
 }
 
-func Restart(ctx context.Context, clients *AWSClients, cluster, service string, kill bool) (*RestartResult, error) {
+// Restart restarts the service by forcing a new deployment, or with
+// opts.Kill by stopping its tasks so ECS replaces them. Tasks that could not
+// be stopped are reported in the result. When a restart in waves aborts, the
+// result lists the tasks stopped so far alongside the error.
+func Restart(ctx context.Context, clients *AWSClients, cluster, service string, opts RestartOptions) (*RestartResult, error) {
 	result := &RestartResult{}
 
-	if kill {
+	if opts.Kill {
 		result.Method = "kill"
 
-		stoppedTasks, err := stopAll(ctx, cluster, service, clients.ECS)
+		err := killTasks(ctx, clients, cluster, service, opts, result)
 		if err != nil {
-			return nil, fmt.Errorf("failed to stop tasks: %w", err)
+			return result, fmt.Errorf("failed to stop tasks: %w", err)
 		}
-		result.StoppedTasks = stoppedTasks
 	} else {
 		result.Method = "force_deploy"
 
-		serviceArn, taskDefinition, err := forceNewDeploy(ctx, cluster, service, clients.ECS)
+		serviceArn, taskDefinition, err := forceNewDeploy(ctx, cluster, service, opts.Revision, clients.ECS)
 		if err != nil {
 			return nil, fmt.Errorf("failed to force new deployment: %w", err)
 		}
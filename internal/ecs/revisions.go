@@ -14,25 +14,60 @@
 package ecs
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"runecs.io/v1/internal/utils"
 )
 
+const (
+	// RevisionService refers to the task definition revision the service runs
+	RevisionService = "service"
+	// RevisionCurrent is another name for RevisionService
+	RevisionCurrent = "current"
+	// RevisionLatest refers to the newest revision registered in the
+	// service's family, whether it was deployed or not
+	RevisionLatest = "latest"
+)
+
+// getFamilies lists the active task definition families starting with
+// familyPrefix; an empty prefix lists every family in the account.
 func getFamilies(ctx context.Context, familyPrefix string, svc *ecs.Client) ([]string, error) {
-	response, err := svc.ListTaskDefinitionFamilies(ctx, &ecs.ListTaskDefinitionFamiliesInput{
-		FamilyPrefix: &familyPrefix,
-	})
+	input := &ecs.ListTaskDefinitionFamiliesInput{
+		Status: types.TaskDefinitionFamilyStatusActive,
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to list task definition families: %w", err)
+	if familyPrefix != "" {
+		input.FamilyPrefix = &familyPrefix
 	}
 
-	return response.Families, nil
+	var families []string
+
+	for {
+		response, err := svc.ListTaskDefinitionFamilies(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list task definition families: %w", err)
+		}
+
+		families = append(families, response.Families...)
+
+		if response.NextToken == nil {
+			break
+		}
+
+		input.NextToken = response.NextToken
+	}
+
+	return families, nil
 }
 
 func getFamilyPrefix(ctx context.Context, cluster, service string, svc *ecs.Client) (string, error) {
@@ -49,19 +84,43 @@ func getFamilyPrefix(ctx context.Context, cluster, service string, svc *ecs.Clie
 		return "", fmt.Errorf("failed to get service information: %w", err)
 	}
 
-	response, err := svc.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
-		TaskDefinition: serviceInfo.TaskDefinition,
-	})
+	if serviceInfo.TaskDefinition == nil {
+		return "", fmt.Errorf("service %s has no task definition", service)
+	}
 
+	taskDef, err := describeTaskDefinitionRevision(ctx, *serviceInfo.TaskDefinition, svc)
 	if err != nil {
-		return "", fmt.Errorf("failed to describe task definition: %w", err)
+		return "", err
 	}
 
-	if response.TaskDefinition.Family == nil {
+	if taskDef.Family == nil {
 		return "", errors.New("task definition has no family name")
 	}
 
-	return *response.TaskDefinition.Family, nil
+	return *taskDef.Family, nil
+}
+
+// serviceTaskDefinitionArn returns the task definition the service is currently
+// configured to run.
+func serviceTaskDefinitionArn(ctx context.Context, cluster, service string, svc *ecs.Client) (string, error) {
+	serviceResponse, err := svc.DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  &cluster,
+		Services: []string{service},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe services: %w", err)
+	}
+
+	serviceInfo, err := utils.SafeGetFirstPtr(serviceResponse.Services, "no services found in response")
+	if err != nil {
+		return "", fmt.Errorf("failed to get service information: %w", err)
+	}
+
+	if serviceInfo.TaskDefinition == nil {
+		return "", fmt.Errorf("service %s has no task definition", service)
+	}
+
+	return *serviceInfo.TaskDefinition, nil
 }
 
 func latestTaskDefinitionArn(ctx context.Context, cluster, service string, svc *ecs.Client) (string, error) {
@@ -87,89 +146,329 @@ func latestTaskDefinitionArn(ctx context.Context, cluster, service string, svc *
 	return arn, nil
 }
 
-func getRevisions(ctx context.Context, familyPrefix string, lastRevisionsNr int, svc *ecs.Client) ([]RevisionEntry, error) {
+// describeConcurrency bounds the number of DescribeTaskDefinition calls in
+// flight at once.
+const describeConcurrency = 8
+
+// describedTaskDefinition is the outcome of describing a single task
+// definition ARN.
+type describedTaskDefinition struct {
+	Arn            string
+	TaskDefinition *types.TaskDefinition
+	Err            error
+}
+
+// describeTaskDefinitions describes the given ARNs with a fixed pool of
+// workers, waiting on limiter before every call and backing off when
+// throttled. Results are returned in the order of arns, with per-ARN errors
+// instead of failing the whole batch. onDescribed, when set, is called after
+// each ARN.
+func describeTaskDefinitions(ctx context.Context, arns []string, svc *ecs.Client, limiter *rateLimiter, onDescribed func()) []describedTaskDefinition {
+	results := make([]describedTaskDefinition, len(arns))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(describeConcurrency, len(arns)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				var taskDef *types.TaskDefinition
+
+				err := retryThrottled(ctx, func() error {
+					err := limiter.Wait(ctx)
+					if err != nil {
+						return err
+					}
+
+					taskDef, err = describeTaskDefinition(ctx, arns[i], svc)
+
+					return err
+				})
+				results[i] = describedTaskDefinition{Arn: arns[i], TaskDefinition: taskDef, Err: err}
+
+				if onDescribed != nil {
+					onDescribed()
+				}
+			}
+		}()
+	}
+
+	for i := range arns {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// listTaskDefinitionArns lists the ARNs of a family with the given status,
+// newest first.
+func listTaskDefinitionArns(ctx context.Context, family string, status types.TaskDefinitionStatus, svc *ecs.Client) ([]string, error) {
 	definitionInput := &ecs.ListTaskDefinitionsInput{
-		FamilyPrefix: &familyPrefix,
+		FamilyPrefix: &family,
 		Sort:         types.SortOrderDesc,
+		Status:       status,
 	}
 
-	var revisions []RevisionEntry
-	total := 0
+	var arns []string
 
 	for {
 		response, err := svc.ListTaskDefinitions(ctx, definitionInput)
-
 		if err != nil {
 			return nil, fmt.Errorf("failed to list task definitions: %w", err)
 		}
 
-		for _, def := range response.TaskDefinitionArns {
-			if lastRevisionsNr != 0 && lastRevisionsNr < (total+1) {
-				break
-			}
+		arns = append(arns, response.TaskDefinitionArns...)
 
-			resp, err := svc.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
-				TaskDefinition: &def,
-			})
+		if response.NextToken == nil {
+			break
+		}
 
-			if err != nil {
-				// Log error but continue processing other revisions
-				continue
-			}
+		definitionInput.NextToken = response.NextToken
+	}
 
-			if resp.TaskDefinition.RegisteredAt == nil {
-				continue // Skip revisions without registration date
-			}
+	return arns, nil
+}
 
-			containerDef, err := utils.SafeGetFirstPtr(resp.TaskDefinition.ContainerDefinitions, "no container definitions found")
-			if err != nil {
-				continue // Skip revisions without container definitions
-			}
+// getServiceDeployments maps task definition ARNs to the services in the
+// cluster whose deployments use them.
+func getServiceDeployments(ctx context.Context, cluster string, svc *ecs.Client) (map[string][]RevisionDeployment, error) {
+	serviceArns, err := getServiceArns(ctx, svc, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := map[string][]RevisionDeployment{}
 
-			if containerDef.Image == nil {
-				continue // Skip revisions without image
+	// DescribeServices accepts at most 10 services per call
+	for batch := range slices.Chunk(serviceArns, 10) {
+		response, err := svc.DescribeServices(ctx, &ecs.DescribeServicesInput{
+			Cluster:  &cluster,
+			Services: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe services in cluster %s: %w", cluster, err)
+		}
+
+		for _, service := range response.Services {
+			for _, deployment := range service.Deployments {
+				if deployment.TaskDefinition == nil {
+					continue
+				}
+
+				deployments[*deployment.TaskDefinition] = append(deployments[*deployment.TaskDefinition], RevisionDeployment{
+					Service: deref(service.ServiceName),
+					Status:  deref(deployment.Status),
+				})
 			}
+		}
+	}
+
+	return deployments, nil
+}
 
-			revisions = append(revisions, RevisionEntry{
-				Revision:  resp.TaskDefinition.Revision,
-				CreatedAt: *resp.TaskDefinition.RegisteredAt,
-				DockerURI: *containerDef.Image,
-				Family:    familyPrefix,
-			})
-			total++
+// principalName shortens the ARN of the principal that registered a revision
+// to its resource, e.g. "assumed-role/deployer/ci".
+func principalName(principal string) string {
+	parsed, err := arn.Parse(principal)
+	if err != nil {
+		return principal
+	}
+
+	return parsed.Resource
+}
+
+func getRevisions(ctx context.Context, familyPrefix string, lastRevisionsNr int, includeInactive bool, svc *ecs.Client) ([]RevisionEntry, error) {
+	arns, err := listTaskDefinitionArns(ctx, familyPrefix, types.TaskDefinitionStatusActive, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	if includeInactive {
+		inactive, err := listTaskDefinitionArns(ctx, familyPrefix, types.TaskDefinitionStatusInactive, svc)
+		if err != nil {
+			return nil, err
 		}
 
-		if response.NextToken == nil {
-			break
+		arns = append(arns, inactive...)
+	}
+
+	if lastRevisionsNr != 0 && len(arns) > lastRevisionsNr {
+		// Both listings are newest first; merge them by revision before cutting
+		slices.SortFunc(arns, func(a, b string) int {
+			return cmp.Compare(revisionNumber(b), revisionNumber(a))
+		})
+
+		arns = arns[:lastRevisionsNr]
+	}
+
+	var revisions []RevisionEntry
+
+	for _, described := range describeTaskDefinitions(ctx, arns, svc, nil, nil) {
+		if described.Err != nil {
+			// Skip revisions that cannot be described
+			continue
 		}
 
-		definitionInput.NextToken = response.NextToken
+		taskDef := described.TaskDefinition
+
+		if taskDef.RegisteredAt == nil {
+			continue // Skip revisions without registration date
+		}
+
+		containerDef, err := utils.SafeGetFirstPtr(taskDef.ContainerDefinitions, "no container definitions found")
+		if err != nil {
+			continue // Skip revisions without container definitions
+		}
+
+		if containerDef.Image == nil {
+			continue // Skip revisions without image
+		}
+
+		revisions = append(revisions, RevisionEntry{
+			Revision:          taskDef.Revision,
+			CreatedAt:         *taskDef.RegisteredAt,
+			DockerURI:         *containerDef.Image,
+			Family:            familyPrefix,
+			TaskDefinitionArn: described.Arn,
+			Cpu:               deref(taskDef.Cpu),
+			Memory:            deref(taskDef.Memory),
+			Status:            string(taskDef.Status),
+			RegisteredBy:      principalName(deref(taskDef.RegisteredBy)),
+		})
 	}
 
 	return revisions, nil
 }
 
-func Revisions(ctx context.Context, clients *AWSClients, cluster, service string, lastRevisionNr int) (*RevisionsResult, error) {
-	familyPrefix, err := getFamilyPrefix(ctx, cluster, service, clients.ECS)
+// revisionNumber returns the revision of a task definition ARN ending in
+// ":<revision>", or 0 when it has none.
+func revisionNumber(taskDefinitionArn string) int {
+	idx := strings.LastIndex(taskDefinitionArn, ":")
+	if idx == -1 {
+		return 0
+	}
+
+	revision, err := strconv.Atoi(taskDefinitionArn[idx+1:])
+	if err != nil {
+		return 0
+	}
+
+	return revision
+}
+
+func Revisions(ctx context.Context, clients *AWSClients, cluster, service string, lastRevisionNr int, includeInactive bool) (*RevisionsResult, error) {
+	family, err := getFamilyPrefix(ctx, cluster, service, clients.ECS)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := getFamilies(ctx, familyPrefix, clients.ECS)
+	deployments, err := getServiceDeployments(ctx, cluster, clients.ECS)
 	if err != nil {
 		return nil, err
 	}
 
-	var allRevisions []RevisionEntry
-	for _, family := range response {
-		revisions, err := getRevisions(ctx, family, lastRevisionNr, clients.ECS)
-		if err != nil {
-			return nil, err
-		}
-		allRevisions = append(allRevisions, revisions...)
+	allRevisions, err := getRevisions(ctx, family, lastRevisionNr, includeInactive, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range allRevisions {
+		allRevisions[i].Deployments = deployments[allRevisions[i].TaskDefinitionArn]
 	}
 
 	return &RevisionsResult{
 		Revisions: allRevisions,
 	}, nil
 }
+
+// ValidateRevision checks a revision reference: "service" (or "current"),
+// "latest" or a revision number. Empty means "service".
+func ValidateRevision(revision string) error {
+	switch revision {
+	case "", RevisionService, RevisionCurrent, RevisionLatest:
+		return nil
+	}
+
+	_, err := parseRevisionNumber(revision)
+
+	return err
+}
+
+// parseRevisionNumber parses a numeric revision reference. Forms such as
+// "+5" or "007" are accepted, so callers must use the number rather than the
+// reference to name the revision.
+func parseRevisionNumber(revision string) (int64, error) {
+	number, err := strconv.ParseInt(revision, 10, 32)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid revision %q: must be %q, %q or a revision number", revision, RevisionService, RevisionLatest)
+	}
+
+	return number, nil
+}
+
+// resolveRevision turns a revision reference into a task definition
+// identifier: "service" is the revision the service runs, "latest" the newest
+// revision of its family and a number that revision of the family. See
+// ValidateRevision for the accepted references.
+func resolveRevision(ctx context.Context, cluster, service, revision string, svc *ecs.Client) (string, error) {
+	err := ValidateRevision(revision)
+	if err != nil {
+		return "", err
+	}
+
+	switch revision {
+	case "", RevisionService, RevisionCurrent:
+		return serviceTaskDefinitionArn(ctx, cluster, service, svc)
+	case RevisionLatest:
+		return latestTaskDefinitionArn(ctx, cluster, service, svc)
+	}
+
+	number, err := parseRevisionNumber(revision)
+	if err != nil {
+		return "", err
+	}
+
+	family, err := getFamilyPrefix(ctx, cluster, service, svc)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%d", family, number), nil
+}
+
+// DiffRevisions compares two task definition revisions of the service. Each
+// revision is a reference accepted by ValidateRevision.
+func DiffRevisions(ctx context.Context, clients *AWSClients, cluster, service, from, to string) (*RevisionDiffResult, error) {
+	fromRef, err := resolveRevision(ctx, cluster, service, from, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	toRef, err := resolveRevision(ctx, cluster, service, to, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDef, err := describeTaskDefinitionRevision(ctx, fromRef, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	toDef, err := describeTaskDefinitionRevision(ctx, toRef, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiffResult{
+		FromTaskDefinitionArn: deref(fromDef.TaskDefinitionArn),
+		ToTaskDefinitionArn:   deref(toDef.TaskDefinitionArn),
+		Changes:               diffTaskDefinitions(fromDef, toDef),
+	}, nil
+}
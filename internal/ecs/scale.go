@@ -17,27 +17,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 )
 
 const (
-	minDesiredCount = 1
 	maxDesiredCount = 1000
 )
 
-var ErrInvalidDesiredCount = errors.New("desired count must be greater than 0")
+var ErrInvalidDesiredCount = errors.New("invalid desired count")
 
-func Scale(ctx context.Context, clients *AWSClients, cluster, service string, desiredCount int32) (*ScaleResult, error) {
-	if desiredCount < minDesiredCount {
-		return nil, fmt.Errorf("%w: got %d, minimum is %d", ErrInvalidDesiredCount, desiredCount, minDesiredCount)
-	}
-
-	if desiredCount > maxDesiredCount {
-		return nil, fmt.Errorf("desired count exceeds maximum: got %d, maximum is %d", desiredCount, maxDesiredCount)
-	}
-
-	describeResp, err := clients.ECS.DescribeServices(ctx, &ecs.DescribeServicesInput{
+// describeService returns an active service
+func describeService(ctx context.Context, cluster, service string, svc *ecs.Client) (*types.Service, error) {
+	describeResp, err := svc.DescribeServices(ctx, &ecs.DescribeServicesInput{
 		Cluster:  &cluster,
 		Services: []string{service},
 	})
@@ -49,20 +45,144 @@ func Scale(ctx context.Context, clients *AWSClients, cluster, service string, de
 		return nil, fmt.Errorf("service %s not found in cluster %s", service, cluster)
 	}
 
-	svc := describeResp.Services[0]
-	if svc.Status == nil || *svc.Status != "ACTIVE" {
+	serviceInfo := &describeResp.Services[0]
+	if serviceInfo.Status == nil || *serviceInfo.Status != "ACTIVE" {
 		return nil, fmt.Errorf("service %s is not in ACTIVE state", service)
 	}
 
-	previousDesiredCount := svc.DesiredCount
+	return serviceInfo, nil
+}
 
-	updateResp, err := clients.ECS.UpdateService(ctx, &ecs.UpdateServiceInput{
-		Cluster:      &cluster,
-		Service:      &service,
-		DesiredCount: &desiredCount,
-	})
+// serviceDesiredCount returns the desired count of an active service
+func serviceDesiredCount(ctx context.Context, cluster, service string, svc *ecs.Client) (int32, error) {
+	serviceInfo, err := describeService(ctx, cluster, service, svc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update service: %w", err)
+		return 0, err
+	}
+
+	return serviceInfo.DesiredCount, nil
+}
+
+// autoScalingBoundsFor returns the bounds that keep desiredCount as the
+// floor of auto scaling: the minimum becomes the new count and the maximum
+// grows when needed, so policies can still scale out but not below it.
+func autoScalingBoundsFor(current AutoScalingBounds, desiredCount int32) AutoScalingBounds {
+	return AutoScalingBounds{
+		MinCapacity: desiredCount,
+		MaxCapacity: max(current.MaxCapacity, desiredCount),
+	}
+}
+
+// autoScalingWarning explains what auto scaling will do to a manually set
+// count, or returns an empty string when the count is within the bounds.
+func autoScalingWarning(bounds AutoScalingBounds, desiredCount int32) string {
+	switch {
+	case desiredCount < bounds.MinCapacity:
+		return fmt.Sprintf("auto scaling manages this service and will scale it back up to its minimum of %d tasks; use --update-autoscaling-bounds to lower the minimum",
+			bounds.MinCapacity)
+	case desiredCount > bounds.MaxCapacity:
+		return fmt.Sprintf("auto scaling manages this service and will scale it back down to its maximum of %d tasks; use --update-autoscaling-bounds to raise the maximum",
+			bounds.MaxCapacity)
+	default:
+		return fmt.Sprintf("auto scaling manages this service between %d and %d tasks and its policies may change the count again; use --update-autoscaling-bounds to keep at least %d",
+			bounds.MinCapacity, bounds.MaxCapacity, desiredCount)
+	}
+}
+
+// ScaleChangeKind tells how a ScaleChange derives the new desired count
+type ScaleChangeKind int
+
+const (
+	// ScaleAbsolute sets the desired count to Count
+	ScaleAbsolute ScaleChangeKind = iota
+	// ScaleRelative adds Count, which may be negative, to the desired count
+	ScaleRelative
+	// ScaleMultiply multiplies the desired count by Factor
+	ScaleMultiply
+)
+
+// ScaleChange is a new desired count given absolutely ("5"), relative to the
+// current count ("+2", "-1") or as a multiple of it ("x2", "x0.5").
+type ScaleChange struct {
+	Kind   ScaleChangeKind
+	Count  int32
+	Factor float64
+}
+
+// ParseScaleChange parses the value of the scale command
+func ParseScaleChange(value string) (ScaleChange, error) {
+	switch {
+	case strings.HasPrefix(value, "x"):
+		factor, err := strconv.ParseFloat(value[1:], 64)
+		if err != nil || factor < 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+			return ScaleChange{}, fmt.Errorf("invalid scale factor %q", value)
+		}
+
+		return ScaleChange{Kind: ScaleMultiply, Factor: factor}, nil
+	case strings.HasPrefix(value, "+"), strings.HasPrefix(value, "-"):
+		delta, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return ScaleChange{}, fmt.Errorf("invalid scale value %q: %w", value, err)
+		}
+
+		return ScaleChange{Kind: ScaleRelative, Count: int32(delta)}, nil
+	default:
+		count, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return ScaleChange{}, fmt.Errorf("invalid scale value %q: %w", value, err)
+		}
+
+		return ScaleChange{Kind: ScaleAbsolute, Count: int32(count)}, nil
+	}
+}
+
+// Apply returns the desired count the change sets for a service currently at
+// current. Multiples are rounded to the nearest count and results never drop
+// below 0.
+func (c ScaleChange) Apply(current int32) int32 {
+	var desired int64
+
+	switch c.Kind {
+	case ScaleRelative:
+		desired = int64(current) + int64(c.Count)
+	case ScaleMultiply:
+		desired = int64(math.Round(float64(current) * c.Factor))
+	default:
+		desired = int64(c.Count)
+	}
+
+	return int32(min(max(desired, 0), math.MaxInt32))
+}
+
+func (c ScaleChange) String() string {
+	switch c.Kind {
+	case ScaleRelative:
+		return fmt.Sprintf("%+d", c.Count)
+	case ScaleMultiply:
+		return "x" + strconv.FormatFloat(c.Factor, 'f', -1, 64)
+	default:
+		return strconv.Itoa(int(c.Count))
+	}
+}
+
+// Scale changes the desired count of the service. When Application Auto
+// Scaling manages the service, the result carries a warning unless
+// opts.UpdateAutoScalingBounds moves the scalable target's bounds so the new
+// count sticks.
+func Scale(ctx context.Context, clients *AWSClients, cluster, service string, change ScaleChange, opts ScaleOptions) (*ScaleResult, error) {
+	previousDesiredCount, err := serviceDesiredCount(ctx, cluster, service, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredCount := change.Apply(previousDesiredCount)
+
+	if desiredCount == 0 && !opts.AllowZero {
+		return nil, fmt.Errorf("%w: scaling to 0 requires allowing it explicitly", ErrInvalidDesiredCount)
+	}
+
+	if desiredCount > maxDesiredCount {
+		return nil, fmt.Errorf("desired count exceeds maximum: got %d, maximum is %d", desiredCount, maxDesiredCount)
 	}
 
 	result := &ScaleResult{
@@ -72,9 +192,89 @@ func Scale(ctx context.Context, clients *AWSClients, cluster, service string, de
 		ServiceName:          service,
 	}
 
+	// Without permissions for Application Auto Scaling the service can still
+	// be scaled, only the auto scaling check is lost
+	target, err := getScalableTarget(ctx, cluster, service, clients.ApplicationAutoScaling)
+	if err != nil {
+		if opts.UpdateAutoScalingBounds {
+			return nil, err
+		}
+
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not check auto scaling: %v", err))
+	}
+
+	if target != nil {
+		result.AutoScaling = scalableTargetBounds(target)
+
+		if opts.UpdateAutoScalingBounds {
+			// Move the bounds first so auto scaling does not undo the new
+			// count between the two calls
+			bounds := autoScalingBoundsFor(*result.AutoScaling, desiredCount)
+			if bounds != *result.AutoScaling {
+				err = updateScalableTargetBounds(ctx, cluster, service, bounds, clients.ApplicationAutoScaling)
+				if err != nil {
+					return nil, err
+				}
+
+				result.UpdatedAutoScaling = &bounds
+			}
+		} else {
+			result.Warnings = append(result.Warnings, autoScalingWarning(*result.AutoScaling, desiredCount))
+		}
+	}
+
+	updateResp, err := clients.ECS.UpdateService(ctx, &ecs.UpdateServiceInput{
+		Cluster:      &cluster,
+		Service:      &service,
+		DesiredCount: &desiredCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service: %w", err)
+	}
+
 	if updateResp.Service != nil && updateResp.Service.ServiceArn != nil {
 		result.ServiceArn = *updateResp.Service.ServiceArn
 	}
 
 	return result, nil
 }
+
+// ScaleServices applies the same change to several services, continuing past
+// services that fail.
+func ScaleServices(ctx context.Context, clients *AWSClients, services []ServiceRef, change ScaleChange, opts ScaleOptions) *BulkScaleResult {
+	result := &BulkScaleResult{}
+
+	for _, ref := range services {
+		scaled, err := Scale(ctx, clients, ref.Cluster, ref.Service, change, opts)
+		if err != nil {
+			result.Failures = append(result.Failures, ScaleFailure{ServiceRef: ref, Err: err})
+
+			continue
+		}
+
+		result.Results = append(result.Results, *scaled)
+	}
+
+	return result
+}
+
+// ListClusterServices returns the names of all services in the cluster
+func ListClusterServices(ctx context.Context, clients *AWSClients, cluster string) ([]ServiceRef, error) {
+	serviceArns, err := getServiceArns(ctx, clients.ECS, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]ServiceRef, 0, len(serviceArns))
+
+	for _, serviceArn := range serviceArns {
+		name, err := extractARNResource(serviceArn)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, ServiceRef{Cluster: cluster, Service: name})
+	}
+
+	return services, nil
+}
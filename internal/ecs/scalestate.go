@@ -0,0 +1,229 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// scaleStateEntry is the desired count, and the auto scaling bounds when
+// they were changed, of a service before a bulk scale. Accounts often share
+// cluster and service names, so the entry names the account too.
+type scaleStateEntry struct {
+	Account      string             `json:"account"`
+	Region       string             `json:"region"`
+	Cluster      string             `json:"cluster"`
+	Service      string             `json:"service"`
+	DesiredCount int32              `json:"desiredCount"`
+	AutoScaling  *AutoScalingBounds `json:"autoScaling,omitempty"`
+	SavedAt      time.Time          `json:"savedAt"`
+}
+
+// scaleState is the content of the scale state file
+type scaleState struct {
+	Services []scaleStateEntry `json:"services"`
+}
+
+// DefaultScaleStatePath returns the file that remembers the desired counts
+// of services scaled in bulk, in the user's configuration directory.
+func DefaultScaleStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the configuration directory: %w", err)
+	}
+
+	return filepath.Join(dir, "runecs", "scale-state.json"), nil
+}
+
+func loadScaleState(path string) (*scaleState, error) {
+	state := &scaleState{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scale state: %w", err)
+	}
+
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scale state %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// saveScaleState writes the state atomically so an interrupted write never
+// loses the remembered counts.
+func saveScaleState(path string, state *scaleState) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create scale state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scale state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write scale state: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed to write scale state: %w", err)
+	}
+
+	return nil
+}
+
+func (s *scaleState) find(account, region string, ref ServiceRef) int {
+	return slices.IndexFunc(s.Services, func(entry scaleStateEntry) bool {
+		return entry.Account == account && entry.Region == region &&
+			entry.Cluster == ref.Cluster && entry.Service == ref.Service
+	})
+}
+
+// scaleStateAccount returns the account the clients work in. It is asked
+// from STS rather than taken from the disk cache, because restoring counts
+// in the wrong account scales the wrong services.
+func scaleStateAccount(ctx context.Context, clients *AWSClients) (string, error) {
+	identity, err := callerIdentity(ctx, clients)
+	if err != nil {
+		return "", err
+	}
+
+	return deref(identity.Account), nil
+}
+
+// RememberScaleState records the counts the services had before a bulk
+// scale. A service that is already remembered keeps its older entry, so
+// scaling an environment down twice still restores the original counts.
+func RememberScaleState(ctx context.Context, clients *AWSClients, path string, results []ScaleResult) error {
+	account, err := scaleStateAccount(ctx, clients)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadScaleState(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for _, result := range results {
+		ref := ServiceRef{Cluster: result.ClusterName, Service: result.ServiceName}
+		if state.find(account, clients.Region, ref) >= 0 {
+			continue
+		}
+
+		entry := scaleStateEntry{
+			Account:      account,
+			Region:       clients.Region,
+			Cluster:      ref.Cluster,
+			Service:      ref.Service,
+			DesiredCount: result.PreviousDesiredCount,
+			SavedAt:      now,
+		}
+
+		if result.UpdatedAutoScaling != nil {
+			entry.AutoScaling = result.AutoScaling
+		}
+
+		state.Services = append(state.Services, entry)
+	}
+
+	return saveScaleState(path, state)
+}
+
+// RestoreScale brings the remembered services of the current account and
+// region back to the desired counts and auto scaling bounds they had before
+// the bulk scale. match selects the services to restore; nil restores all of
+// them. Restored services are forgotten, failed ones stay remembered for
+// another attempt.
+func RestoreScale(ctx context.Context, clients *AWSClients, path string, match func(ServiceRef) bool) (*BulkScaleResult, error) {
+	account, err := scaleStateAccount(ctx, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadScaleState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkScaleResult{}
+
+	var remaining []scaleStateEntry
+
+	for _, entry := range state.Services {
+		ref := ServiceRef{Cluster: entry.Cluster, Service: entry.Service}
+
+		if entry.Account != account || entry.Region != clients.Region || (match != nil && !match(ref)) {
+			remaining = append(remaining, entry)
+
+			continue
+		}
+
+		if entry.AutoScaling != nil {
+			err = updateScalableTargetBounds(ctx, ref.Cluster, ref.Service, *entry.AutoScaling, clients.ApplicationAutoScaling)
+			if err != nil {
+				result.Failures = append(result.Failures, ScaleFailure{ServiceRef: ref, Err: err})
+				remaining = append(remaining, entry)
+
+				continue
+			}
+		}
+
+		scaled, err := Scale(ctx, clients, ref.Cluster, ref.Service,
+			ScaleChange{Kind: ScaleAbsolute, Count: entry.DesiredCount}, ScaleOptions{AllowZero: true})
+		if err != nil {
+			result.Failures = append(result.Failures, ScaleFailure{ServiceRef: ref, Err: err})
+			remaining = append(remaining, entry)
+
+			continue
+		}
+
+		if entry.AutoScaling != nil {
+			// The remembered bounds were put back above
+			scaled.UpdatedAutoScaling = entry.AutoScaling
+			scaled.Warnings = nil
+		}
+
+		result.Results = append(result.Results, *scaled)
+	}
+
+	state.Services = remaining
+
+	err = saveScaleState(path, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,265 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+)
+
+// scheduledTaskDefinitions collects the task definitions that EventBridge
+// rules and EventBridge Scheduler schedules start. Every AWS call waits on
+// the prune's rate limiter.
+type scheduledTaskDefinitions struct {
+	clients *AWSClients
+	limiter *rateLimiter
+	// inUse maps the task definitions to the reason they must be kept
+	inUse    map[string]string
+	warnings []string
+}
+
+// hasRevision reports whether a task definition reference names a revision.
+// References without one run the latest ACTIVE revision of the family.
+func hasRevision(taskDefinition string) bool {
+	_, name, found := strings.Cut(taskDefinition, "task-definition/")
+	if !found {
+		name = taskDefinition
+	}
+
+	return strings.Contains(name, ":")
+}
+
+// add records the task definition a rule or schedule starts, resolving a
+// family to the revision it currently runs. A family that cannot be resolved,
+// for example because it no longer exists, has nothing to keep and is only
+// reported.
+func (s *scheduledTaskDefinitions) add(ctx context.Context, taskDefinition, reason string) {
+	if !hasRevision(taskDefinition) {
+		var taskDef *types.TaskDefinition
+
+		err := limitedCall(ctx, s.limiter, func() error {
+			var err error
+
+			taskDef, err = describeTaskDefinition(ctx, taskDefinition, s.clients.ECS)
+
+			return err
+		})
+		if err != nil {
+			s.warnings = append(s.warnings, fmt.Sprintf("%s: %v", reason, err))
+
+			return
+		}
+
+		taskDefinition = deref(taskDef.TaskDefinitionArn)
+	}
+
+	if _, ok := s.inUse[taskDefinition]; !ok {
+		s.inUse[taskDefinition] = reason
+	}
+}
+
+// listEventBuses returns the names of the event buses in the region
+func (s *scheduledTaskDefinitions) listEventBuses(ctx context.Context) ([]string, error) {
+	var buses []string
+
+	input := &eventbridge.ListEventBusesInput{}
+
+	for {
+		var response *eventbridge.ListEventBusesOutput
+
+		err := limitedCall(ctx, s.limiter, func() error {
+			var err error
+
+			response, err = s.clients.EventBridge.ListEventBuses(ctx, input)
+
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list event buses: %w", err)
+		}
+
+		for _, bus := range response.EventBuses {
+			buses = append(buses, deref(bus.Name))
+		}
+
+		if response.NextToken == nil {
+			return buses, nil
+		}
+
+		input.NextToken = response.NextToken
+	}
+}
+
+// addRuleTargets records the task definitions started by the targets of a
+// rule
+func (s *scheduledTaskDefinitions) addRuleTargets(ctx context.Context, bus, rule string) error {
+	input := &eventbridge.ListTargetsByRuleInput{Rule: &rule, EventBusName: &bus}
+
+	for {
+		var response *eventbridge.ListTargetsByRuleOutput
+
+		err := limitedCall(ctx, s.limiter, func() error {
+			var err error
+
+			response, err = s.clients.EventBridge.ListTargetsByRule(ctx, input)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list targets of EventBridge rule %s: %w", rule, err)
+		}
+
+		for _, target := range response.Targets {
+			if target.EcsParameters == nil || target.EcsParameters.TaskDefinitionArn == nil {
+				continue
+			}
+
+			s.add(ctx, *target.EcsParameters.TaskDefinitionArn, fmt.Sprintf("in use by EventBridge rule %s/%s", bus, rule))
+		}
+
+		if response.NextToken == nil {
+			return nil
+		}
+
+		input.NextToken = response.NextToken
+	}
+}
+
+// addRules records the task definitions started by EventBridge rules on any
+// event bus
+func (s *scheduledTaskDefinitions) addRules(ctx context.Context) error {
+	buses, err := s.listEventBuses(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bus := range buses {
+		input := &eventbridge.ListRulesInput{EventBusName: &bus}
+
+		for {
+			var response *eventbridge.ListRulesOutput
+
+			err := limitedCall(ctx, s.limiter, func() error {
+				var err error
+
+				response, err = s.clients.EventBridge.ListRules(ctx, input)
+
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list EventBridge rules on bus %s: %w", bus, err)
+			}
+
+			for _, rule := range response.Rules {
+				err := s.addRuleTargets(ctx, bus, deref(rule.Name))
+				if err != nil {
+					return err
+				}
+			}
+
+			if response.NextToken == nil {
+				break
+			}
+
+			input.NextToken = response.NextToken
+		}
+	}
+
+	return nil
+}
+
+// addSchedules records the task definitions started by EventBridge Scheduler
+// schedules in any group
+func (s *scheduledTaskDefinitions) addSchedules(ctx context.Context) error {
+	input := &scheduler.ListSchedulesInput{}
+
+	for {
+		var response *scheduler.ListSchedulesOutput
+
+		err := limitedCall(ctx, s.limiter, func() error {
+			var err error
+
+			response, err = s.clients.Scheduler.ListSchedules(ctx, input)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list EventBridge Scheduler schedules: %w", err)
+		}
+
+		for _, summary := range response.Schedules {
+			// Only schedules targeting an ECS cluster can run a task definition
+			if summary.Target == nil || !strings.Contains(deref(summary.Target.Arn), ":ecs:") {
+				continue
+			}
+
+			var schedule *scheduler.GetScheduleOutput
+
+			err := limitedCall(ctx, s.limiter, func() error {
+				var err error
+
+				schedule, err = s.clients.Scheduler.GetSchedule(ctx, &scheduler.GetScheduleInput{
+					Name:      summary.Name,
+					GroupName: summary.GroupName,
+				})
+
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get schedule %s: %w", deref(summary.Name), err)
+			}
+
+			if schedule.Target == nil || schedule.Target.EcsParameters == nil || schedule.Target.EcsParameters.TaskDefinitionArn == nil {
+				continue
+			}
+
+			s.add(ctx, *schedule.Target.EcsParameters.TaskDefinitionArn,
+				fmt.Sprintf("in use by EventBridge Scheduler schedule %s/%s", deref(summary.GroupName), deref(summary.Name)))
+		}
+
+		if response.NextToken == nil {
+			return nil
+		}
+
+		input.NextToken = response.NextToken
+	}
+}
+
+// getScheduledTaskDefinitions maps every task definition that an EventBridge
+// rule or an EventBridge Scheduler schedule starts to the reason it must be
+// kept. Many roles that may prune have no EventBridge permissions, so a
+// source that cannot be read is reported in the warnings and skipped instead
+// of failing the prune.
+func getScheduledTaskDefinitions(ctx context.Context, clients *AWSClients, limiter *rateLimiter) (map[string]string, []string) {
+	scheduled := &scheduledTaskDefinitions{clients: clients, limiter: limiter, inUse: map[string]string{}}
+
+	err := scheduled.addRules(ctx)
+	if err != nil {
+		scheduled.warnings = append(scheduled.warnings,
+			fmt.Sprintf("revisions started by EventBridge rules were not checked: %v", err))
+	}
+
+	err = scheduled.addSchedules(ctx)
+	if err != nil {
+		scheduled.warnings = append(scheduled.warnings,
+			fmt.Sprintf("revisions started by EventBridge Scheduler schedules were not checked: %v", err))
+	}
+
+	return scheduled.inUse, scheduled.warnings
+}
@@ -0,0 +1,241 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+)
+
+// targetGroupName returns the name in a target group ARN such as
+// arn:aws:elasticloadbalancing:...:targetgroup/web/0123456789abcdef
+func targetGroupName(targetGroupArn string) string {
+	parsed, err := arn.Parse(targetGroupArn)
+	if err != nil {
+		return targetGroupArn
+	}
+
+	resource, ok := strings.CutPrefix(parsed.Resource, "targetgroup/")
+	if !ok {
+		return targetGroupArn
+	}
+
+	name, _, _ := strings.Cut(resource, "/")
+
+	return name
+}
+
+// getTargetHealth returns the targets of the load balancer target groups of
+// a service. Targets are matched to tasks by the awsvpc IP of the task.
+func getTargetHealth(ctx context.Context, loadBalancers []types.LoadBalancer, tasks []types.Task, client *elasticloadbalancingv2.Client) ([]TargetStatus, error) {
+	taskIDs := map[string]string{}
+
+	for _, task := range tasks {
+		if ip := taskPrivateIP(task); ip != "" {
+			taskIDs[ip] = taskID(task)
+		}
+	}
+
+	var targets []TargetStatus
+
+	for _, loadBalancer := range loadBalancers {
+		if loadBalancer.TargetGroupArn == nil {
+			continue
+		}
+
+		response, err := client.DescribeTargetHealth(ctx, &elasticloadbalancingv2.DescribeTargetHealthInput{
+			TargetGroupArn: loadBalancer.TargetGroupArn,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe target health: %w", err)
+		}
+
+		for _, description := range response.TargetHealthDescriptions {
+			if description.Target == nil || description.TargetHealth == nil {
+				continue
+			}
+
+			target := TargetStatus{
+				TargetGroupArn: *loadBalancer.TargetGroupArn,
+				TargetGroup:    targetGroupName(*loadBalancer.TargetGroupArn),
+				Target:         deref(description.Target.Id),
+				TaskID:         taskIDs[deref(description.Target.Id)],
+				State:          string(description.TargetHealth.State),
+				Reason:         string(description.TargetHealth.Reason),
+				Description:    deref(description.TargetHealth.Description),
+			}
+
+			if description.Target.Port != nil {
+				target.Port = *description.Target.Port
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, nil
+}
+
+// taskID returns the ID of a task, or its ARN when the ARN is malformed
+func taskID(task types.Task) string {
+	id, err := extractARNResource(deref(task.TaskArn))
+	if err != nil {
+		return deref(task.TaskArn)
+	}
+
+	return id
+}
+
+// taskDefinitionRevision returns family:revision of a task definition ARN
+func taskDefinitionRevision(taskDefinitionArn string) string {
+	revision, err := extractARNResource(taskDefinitionArn)
+	if err != nil {
+		return taskDefinitionArn
+	}
+
+	return revision
+}
+
+func deploymentStatus(deployment types.Deployment) DeploymentStatus {
+	status := DeploymentStatus{
+		ID:                 deref(deployment.Id),
+		Status:             deref(deployment.Status),
+		TaskDefinition:     taskDefinitionRevision(deref(deployment.TaskDefinition)),
+		DesiredCount:       deployment.DesiredCount,
+		RunningCount:       deployment.RunningCount,
+		PendingCount:       deployment.PendingCount,
+		FailedTasks:        deployment.FailedTasks,
+		RolloutState:       string(deployment.RolloutState),
+		RolloutStateReason: deref(deployment.RolloutStateReason),
+	}
+
+	if deployment.CreatedAt != nil {
+		status.CreatedAt = *deployment.CreatedAt
+	}
+
+	if deployment.UpdatedAt != nil {
+		status.UpdatedAt = *deployment.UpdatedAt
+	}
+
+	return status
+}
+
+func taskStatus(task types.Task) TaskStatus {
+	status := TaskStatus{
+		ID:               taskID(task),
+		TaskDefinition:   taskDefinitionRevision(deref(task.TaskDefinitionArn)),
+		LastStatus:       deref(task.LastStatus),
+		HealthStatus:     string(task.HealthStatus),
+		AvailabilityZone: deref(task.AvailabilityZone),
+		PrivateIP:        taskPrivateIP(task),
+		RunningTime:      "Unknown",
+	}
+
+	if task.StartedAt != nil {
+		status.StartedAt = *task.StartedAt
+		status.RunningTime = formatRunningTime(time.Since(*task.StartedAt))
+	}
+
+	return status
+}
+
+// GetServiceStatus collects the state of a service in one view: its counts,
+// deployments and their rollout state, running tasks, load balancer target
+// health and the last eventCount service events.
+func GetServiceStatus(ctx context.Context, clients *AWSClients, cluster, service string, eventCount int) (*ServiceStatus, error) {
+	serviceInfo, err := describeService(ctx, cluster, service, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := describeServiceTasks(ctx, cluster, service, types.DesiredStatusRunning, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first; tasks that have not started yet go last
+	slices.SortFunc(tasks, func(a, b types.Task) int {
+		switch {
+		case a.StartedAt == nil && b.StartedAt == nil:
+			return strings.Compare(deref(a.TaskArn), deref(b.TaskArn))
+		case a.StartedAt == nil:
+			return 1
+		case b.StartedAt == nil:
+			return -1
+		}
+
+		if order := a.StartedAt.Compare(*b.StartedAt); order != 0 {
+			return order
+		}
+
+		return strings.Compare(deref(a.TaskArn), deref(b.TaskArn))
+	})
+
+	targets, err := getTargetHealth(ctx, serviceInfo.LoadBalancers, tasks, clients.ELB)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ServiceStatus{
+		Cluster:        cluster,
+		Service:        service,
+		Region:         clients.Region,
+		Status:         deref(serviceInfo.Status),
+		TaskDefinition: taskDefinitionRevision(deref(serviceInfo.TaskDefinition)),
+		LaunchType:     string(serviceInfo.LaunchType),
+		DesiredCount:   serviceInfo.DesiredCount,
+		RunningCount:   serviceInfo.RunningCount,
+		PendingCount:   serviceInfo.PendingCount,
+		Deployments:    []DeploymentStatus{},
+		Tasks:          []TaskStatus{},
+		Targets:        targets,
+		Events:         []ServiceEvent{},
+	}
+
+	if status.Targets == nil {
+		status.Targets = []TargetStatus{}
+	}
+
+	if serviceInfo.DeploymentConfiguration != nil && serviceInfo.DeploymentConfiguration.DeploymentCircuitBreaker != nil {
+		breaker := serviceInfo.DeploymentConfiguration.DeploymentCircuitBreaker
+		status.CircuitBreaker = &CircuitBreakerStatus{Enabled: breaker.Enable, Rollback: breaker.Rollback}
+	}
+
+	for _, deployment := range serviceInfo.Deployments {
+		status.Deployments = append(status.Deployments, deploymentStatus(deployment))
+	}
+
+	for _, task := range tasks {
+		status.Tasks = append(status.Tasks, taskStatus(task))
+	}
+
+	// ECS returns the events newest first
+	for _, event := range serviceInfo.Events[:min(eventCount, len(serviceInfo.Events))] {
+		serviceEvent := ServiceEvent{Message: deref(event.Message)}
+		if event.CreatedAt != nil {
+			serviceEvent.CreatedAt = *event.CreatedAt
+		}
+
+		status.Events = append(status.Events, serviceEvent)
+	}
+
+	return status, nil
+}
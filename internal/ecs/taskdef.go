@@ -0,0 +1,107 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/jinzhu/copier"
+)
+
+const (
+	// DocumentFormatJSON renders documents as JSON
+	DocumentFormatJSON = "json"
+	// DocumentFormatYAML renders documents as YAML
+	DocumentFormatYAML = "yaml"
+)
+
+// taskDefinitionInput converts a described task definition into registration
+// input. The fields RegisterTaskDefinition accepts are listed one by one, so
+// the read-only TaskDefinitionArn, Revision, Status, RegisteredAt,
+// RegisteredBy, DeregisteredAt, RequiresAttributes and Compatibilities never
+// reach the input. The input is a deep copy and may be changed freely.
+func taskDefinitionInput(taskDef *types.TaskDefinition, tags []types.Tag) (*ecs.RegisterTaskDefinitionInput, error) {
+	source := &types.TaskDefinition{}
+
+	err := copier.CopyWithOption(source, taskDef, copier.Option{DeepCopy: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy task definition: %w", err)
+	}
+
+	input := &ecs.RegisterTaskDefinitionInput{
+		ContainerDefinitions:    source.ContainerDefinitions,
+		Family:                  source.Family,
+		Cpu:                     source.Cpu,
+		EnableFaultInjection:    source.EnableFaultInjection,
+		EphemeralStorage:        source.EphemeralStorage,
+		ExecutionRoleArn:        source.ExecutionRoleArn,
+		InferenceAccelerators:   source.InferenceAccelerators,
+		IpcMode:                 source.IpcMode,
+		Memory:                  source.Memory,
+		NetworkMode:             source.NetworkMode,
+		PidMode:                 source.PidMode,
+		PlacementConstraints:    source.PlacementConstraints,
+		ProxyConfiguration:      source.ProxyConfiguration,
+		RequiresCompatibilities: source.RequiresCompatibilities,
+		RuntimePlatform:         source.RuntimePlatform,
+		TaskRoleArn:             source.TaskRoleArn,
+		Volumes:                 source.Volumes,
+	}
+
+	if len(tags) > 0 {
+		input.Tags = tags
+	}
+
+	return input, nil
+}
+
+// ExportTaskDefinition returns the service's task definition as a registrable
+// JSON or YAML document. The revision is a reference accepted by
+// ValidateRevision.
+func ExportTaskDefinition(ctx context.Context, clients *AWSClients, cluster, service, revision, format string) (*TaskDefinitionExportResult, error) {
+	taskDefinition, err := resolveRevision(ctx, cluster, service, revision, clients.ECS)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := clients.ECS.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: &taskDefinition,
+		Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe task definition %s: %w", taskDefinition, err)
+	}
+
+	if response.TaskDefinition == nil {
+		return nil, fmt.Errorf("task definition %s not found", taskDefinition)
+	}
+
+	input, err := taskDefinitionInput(response.TaskDefinition, response.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := encodeDocument(input, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskDefinitionExportResult{
+		TaskDefinitionArn: deref(response.TaskDefinition.TaskDefinitionArn),
+		Document:          document,
+	}, nil
+}
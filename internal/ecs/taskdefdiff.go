@@ -0,0 +1,235 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/buildkite/shellwords"
+)
+
+// deref returns the value of a string pointer or an empty string
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func derefInt32(value *int32) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", *value)
+}
+
+// joinCommand renders a command as a single shell-quoted line
+func joinCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, word := range command {
+		quoted[i] = shellwords.Quote(word)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// formatPortMappings renders port mappings as a sorted list such as
+// "80:8080/tcp, 443/tcp"
+func formatPortMappings(mappings []types.PortMapping) string {
+	ports := make([]string, 0, len(mappings))
+
+	for _, mapping := range mappings {
+		port := derefInt32(mapping.ContainerPort)
+		if mapping.HostPort != nil && *mapping.HostPort != 0 && derefInt32(mapping.HostPort) != port {
+			port = derefInt32(mapping.HostPort) + ":" + port
+		}
+
+		protocol := string(mapping.Protocol)
+		if protocol == "" {
+			protocol = string(types.TransportProtocolTcp)
+		}
+
+		ports = append(ports, port+"/"+protocol)
+	}
+
+	sort.Strings(ports)
+
+	return strings.Join(ports, ", ")
+}
+
+// formatHealthCheck renders a container health check on a single line
+func formatHealthCheck(check *types.HealthCheck) string {
+	if check == nil {
+		return ""
+	}
+
+	parts := []string{joinCommand(check.Command)}
+
+	if check.Interval != nil {
+		parts = append(parts, fmt.Sprintf("interval=%ds", *check.Interval))
+	}
+	if check.Timeout != nil {
+		parts = append(parts, fmt.Sprintf("timeout=%ds", *check.Timeout))
+	}
+	if check.Retries != nil {
+		parts = append(parts, fmt.Sprintf("retries=%d", *check.Retries))
+	}
+	if check.StartPeriod != nil {
+		parts = append(parts, fmt.Sprintf("start-period=%ds", *check.StartPeriod))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func logOptionsMap(config *types.LogConfiguration) map[string]string {
+	if config == nil {
+		return map[string]string{}
+	}
+
+	return config.Options
+}
+
+func logDriver(config *types.LogConfiguration) string {
+	if config == nil {
+		return ""
+	}
+
+	return string(config.LogDriver)
+}
+
+func environmentMap(env []types.KeyValuePair) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, pair := range env {
+		values[deref(pair.Name)] = deref(pair.Value)
+	}
+
+	return values
+}
+
+func secretsMap(secrets []types.Secret) map[string]string {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		values[deref(secret.Name)] = deref(secret.ValueFrom)
+	}
+
+	return values
+}
+
+// diffMaps appends a change for every key whose value differs between the maps
+func diffMaps(changes []TaskDefinitionChange, container, field string, old, new map[string]string) []TaskDefinitionChange {
+	keys := make([]string, 0, len(old)+len(new))
+	for key := range old {
+		keys = append(keys, key)
+	}
+	for key := range new {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldValue, oldOk := old[key]
+		newValue, newOk := new[key]
+		if oldOk == newOk && oldValue == newValue {
+			continue
+		}
+
+		change := TaskDefinitionChange{Container: container, Field: field + " " + key}
+		if oldOk {
+			change.Old = oldValue
+		}
+		if newOk {
+			change.New = newValue
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+func appendChange(changes []TaskDefinitionChange, container, field, old, new string) []TaskDefinitionChange {
+	if old == new {
+		return changes
+	}
+
+	return append(changes, TaskDefinitionChange{Container: container, Field: field, Old: old, New: new})
+}
+
+func diffContainers(changes []TaskDefinitionChange, old, new *types.ContainerDefinition) []TaskDefinitionChange {
+	name := deref(new.Name)
+
+	changes = appendChange(changes, name, "image", deref(old.Image), deref(new.Image))
+	changes = appendChange(changes, name, "command", joinCommand(old.Command), joinCommand(new.Command))
+	changes = appendChange(changes, name, "cpu", fmt.Sprintf("%d", old.Cpu), fmt.Sprintf("%d", new.Cpu))
+	changes = appendChange(changes, name, "memory", derefInt32(old.Memory), derefInt32(new.Memory))
+	changes = appendChange(changes, name, "memory reservation", derefInt32(old.MemoryReservation), derefInt32(new.MemoryReservation))
+	changes = diffMaps(changes, name, "env", environmentMap(old.Environment), environmentMap(new.Environment))
+	changes = diffMaps(changes, name, "secret", secretsMap(old.Secrets), secretsMap(new.Secrets))
+	changes = appendChange(changes, name, "ports", formatPortMappings(old.PortMappings), formatPortMappings(new.PortMappings))
+	changes = appendChange(changes, name, "health check", formatHealthCheck(old.HealthCheck), formatHealthCheck(new.HealthCheck))
+	changes = appendChange(changes, name, "log driver", logDriver(old.LogConfiguration), logDriver(new.LogConfiguration))
+	changes = diffMaps(changes, name, "log option", logOptionsMap(old.LogConfiguration), logOptionsMap(new.LogConfiguration))
+
+	return changes
+}
+
+// diffTaskDefinitions returns the differences between two task definitions.
+// Containers are matched by name; secrets are compared by their references.
+func diffTaskDefinitions(old, new *types.TaskDefinition) []TaskDefinitionChange {
+	var changes []TaskDefinitionChange
+
+	changes = appendChange(changes, "", "cpu", deref(old.Cpu), deref(new.Cpu))
+	changes = appendChange(changes, "", "memory", deref(old.Memory), deref(new.Memory))
+	changes = appendChange(changes, "", "network mode", string(old.NetworkMode), string(new.NetworkMode))
+	changes = appendChange(changes, "", "task role", deref(old.TaskRoleArn), deref(new.TaskRoleArn))
+	changes = appendChange(changes, "", "execution role", deref(old.ExecutionRoleArn), deref(new.ExecutionRoleArn))
+
+	oldContainers := make(map[string]*types.ContainerDefinition, len(old.ContainerDefinitions))
+	for i := range old.ContainerDefinitions {
+		oldContainers[deref(old.ContainerDefinitions[i].Name)] = &old.ContainerDefinitions[i]
+	}
+
+	seen := make(map[string]bool, len(new.ContainerDefinitions))
+
+	for i := range new.ContainerDefinitions {
+		newContainer := &new.ContainerDefinitions[i]
+		name := deref(newContainer.Name)
+		seen[name] = true
+
+		oldContainer, ok := oldContainers[name]
+		if !ok {
+			changes = append(changes, TaskDefinitionChange{Container: name, Field: "container", New: deref(newContainer.Image)})
+
+			continue
+		}
+
+		changes = diffContainers(changes, oldContainer, newContainer)
+	}
+
+	for i := range old.ContainerDefinitions {
+		name := deref(old.ContainerDefinitions[i].Name)
+		if !seen[name] {
+			changes = append(changes, TaskDefinitionChange{Container: name, Field: "container", Old: deref(old.ContainerDefinitions[i].Image)})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"gopkg.in/yaml.v3"
+)
+
+// taskDefinitionReadOnlyFields are returned by DescribeTaskDefinition but
+// rejected by RegisterTaskDefinition.
+var taskDefinitionReadOnlyFields = []string{
+	"taskDefinitionArn",
+	"revision",
+	"status",
+	"requiresAttributes",
+	"compatibilities",
+	"registeredAt",
+	"registeredBy",
+	"deregisteredAt",
+}
+
+// placeholderPattern matches ${NAME} placeholders. The bare $NAME form is
+// deliberately not supported so shell variables in commands stay untouched.
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// renderPlaceholders replaces ${NAME} placeholders in every string value of a
+// decoded document. Values come from vars first and the environment second.
+func renderPlaceholders(value any, vars map[string]string, missing map[string]bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			typed[key] = renderPlaceholders(item, vars, missing)
+		}
+
+		return typed
+	case []any:
+		for i, item := range typed {
+			typed[i] = renderPlaceholders(item, vars, missing)
+		}
+
+		return typed
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(typed, func(match string) string {
+			name := placeholderPattern.FindStringSubmatch(match)[1]
+
+			if replacement, ok := vars[name]; ok {
+				return replacement
+			}
+
+			if replacement, ok := os.LookupEnv(name); ok {
+				return replacement
+			}
+
+			missing[name] = true
+
+			return match
+		})
+	default:
+		return value
+	}
+}
+
+// decodeTaskDefinitionDocument parses JSON or YAML, chosen by file extension,
+// into a generic document.
+func decodeTaskDefinitionDocument(path string, data []byte) (map[string]any, error) {
+	document := map[string]any{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err := yaml.Unmarshal(data, &document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	default:
+		err := json.Unmarshal(data, &document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+
+	return document, nil
+}
+
+// LoadTaskDefinitionFile reads a task definition document and returns it as
+// registration input. The document has the shape of
+// `aws ecs describe-task-definition` output, either wrapped in a
+// "taskDefinition" key or bare. Read-only fields are removed and ${NAME}
+// placeholders are rendered from vars and the environment.
+func LoadTaskDefinitionFile(path string, vars map[string]string) (*ecs.RegisterTaskDefinitionInput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task definition file: %w", err)
+	}
+
+	document, err := decodeTaskDefinitionDocument(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	// describe-task-definition output wraps the definition and lists tags next to it
+	if wrapped, ok := document["taskDefinition"].(map[string]any); ok {
+		if tags, ok := document["tags"]; ok {
+			wrapped["tags"] = tags
+		}
+
+		document = wrapped
+	}
+
+	for _, field := range taskDefinitionReadOnlyFields {
+		delete(document, field)
+	}
+
+	missing := map[string]bool{}
+	renderPlaceholders(document, vars, missing)
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("undefined placeholders in %s: %s", path, strings.Join(names, ", "))
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task definition: %w", err)
+	}
+
+	// The SDK types carry no JSON tags; encoding/json matches the camelCase
+	// document keys to the field names case-insensitively.
+	input := &ecs.RegisterTaskDefinitionInput{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task definition in %s: %w", path, err)
+	}
+
+	if input.Family == nil || *input.Family == "" {
+		return nil, fmt.Errorf("task definition in %s has no family", path)
+	}
+
+	if len(input.ContainerDefinitions) == 0 {
+		return nil, errors.New("task definition has no container definitions")
+	}
+
+	return input, nil
+}
@@ -14,10 +14,16 @@
 package ecs
 
 import (
+	"context"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
@@ -27,10 +33,18 @@ const (
 
 // AWSClients holds initialized AWS service clients
 type AWSClients struct {
-	ECS            *ecs.Client
-	CloudWatchLogs *cloudwatchlogs.Client
-	STS            *sts.Client
-	Region         string
+	ECS                    *ecs.Client
+	CloudWatchLogs         *cloudwatchlogs.Client
+	CloudWatch             *cloudwatch.Client
+	STS                    *sts.Client
+	ApplicationAutoScaling *applicationautoscaling.Client
+	ELB                    *elasticloadbalancingv2.Client
+	EventBridge            *eventbridge.Client
+	Scheduler              *scheduler.Client
+	Region                 string
+	// Profile is the AWS profile the clients were created with, empty for
+	// the default credentials
+	Profile string
 }
 
 // TaskDefinition represents task definition metadata
@@ -50,6 +64,40 @@ type LogEntry struct {
 	Timestamp  int64
 }
 
+// LiveTail is a running CloudWatch Live Tail session. Logs carries the log
+// events and Notices carries warnings about the session itself, such as
+// sampled results. Both channels are closed when the session ends.
+type LiveTail struct {
+	Logs    <-chan LogEntry
+	Notices <-chan string
+	cancel  context.CancelFunc
+}
+
+// Close stops the live tail session.
+func (t *LiveTail) Close() {
+	t.cancel()
+}
+
+// ExportedFile describes a file written by a log export
+type ExportedFile struct {
+	Name   string `json:"name"`
+	Events int    `json:"events"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// LogExportResult contains the result of a log export
+type LogExportResult struct {
+	LogGroup        string
+	LogStreamPrefix string
+	OutputDir       string
+	CheckpointPath  string
+	Files           []ExportedFile
+	Events          int
+	Bytes           int64
+	Resumed         bool
+	Completed       bool
+}
+
 // LogStreamPrefix represents log configuration for a container
 type LogStreamPrefix struct {
 	LogGroup      string
@@ -66,12 +114,38 @@ type ExecuteResult struct {
 	Logs              []LogEntry
 }
 
+// TaskDefinitionExportResult contains an exported task definition document
+type TaskDefinitionExportResult struct {
+	TaskDefinitionArn string
+	Document          []byte
+}
+
+// RevisionDeployment represents a service deployment using a revision
+type RevisionDeployment struct {
+	Service string
+	Status  string // "PRIMARY" or "ACTIVE"
+}
+
 // RevisionEntry represents a single task definition revision
 type RevisionEntry struct {
-	Revision  int32
-	CreatedAt time.Time
-	DockerURI string
-	Family    string
+	Revision          int32
+	CreatedAt         time.Time
+	DockerURI         string
+	Family            string
+	TaskDefinitionArn string
+	Cpu               string
+	Memory            string
+	Status            string
+	RegisteredBy      string
+	Deployments       []RevisionDeployment
+}
+
+// RevisionDiffResult contains the differences between two task definition
+// revisions
+type RevisionDiffResult struct {
+	FromTaskDefinitionArn string
+	ToTaskDefinitionArn   string
+	Changes               []TaskDefinitionChange
 }
 
 // RevisionsResult contains the list of task definition revisions
@@ -79,18 +153,99 @@ type RevisionsResult struct {
 	Revisions []RevisionEntry
 }
 
+// DeployOptions describes the changes a deploy applies to the cloned task
+// definition. Empty fields leave the current value untouched.
+type DeployOptions struct {
+	ImageTag string
+	Image    string
+	SetEnv   map[string]string
+	UnsetEnv []string
+	Secrets  map[string]string // variable name to secret ARN
+	Cpu      string
+	Memory   string
+	Command  []string
+	// Revision selects the revision to clone, see ValidateRevision; empty
+	// clones the revision the service runs
+	Revision string
+	// OnChanges, if set, is called with the changes before anything is
+	// registered or updated
+	OnChanges DeployChangesFunc
+}
+
+// DeployChangesFunc receives the changes a deploy makes against the revision
+// the service currently runs
+type DeployChangesFunc func(previousTaskDefinitionArn string, changes []TaskDefinitionChange)
+
+// TaskDefinitionChange represents a single difference between two task
+// definitions. Container is empty for task-level fields; an empty Old or New
+// means the value was added or removed.
+type TaskDefinitionChange struct {
+	Container string
+	Field     string
+	Old       string
+	New       string
+}
+
 // DeployResult contains the result of a deployment operation
 type DeployResult struct {
-	TaskDefinitionArn string
-	ServiceArn        string
+	TaskDefinitionArn         string
+	PreviousTaskDefinitionArn string
+	ServiceArn                string
+	Image                     string
+	Changes                   []TaskDefinitionChange
+	DryRun                    bool
+}
+
+// RestartOptions controls how a service is restarted
+type RestartOptions struct {
+	// Kill stops the running tasks instead of forcing a new deployment
+	Kill bool
+	// TaskIDs, OlderThan and Unhealthy select the tasks Kill stops. A task
+	// must match every selector that is set; without selectors all running
+	// tasks are stopped.
+	TaskIDs   []string
+	OlderThan time.Duration
+	Unhealthy bool
+	// StopReason is recorded on the stopped tasks
+	StopReason string
+	// BatchSize stops at most this many tasks per wave and waits for healthy
+	// replacements before the next wave. 0 stops all tasks at once.
+	BatchSize int
+	// Interval is an extra pause after the replacements of a wave are healthy
+	Interval time.Duration
+	// HealthTimeout is how long to wait for the replacements of a wave
+	HealthTimeout time.Duration
+	// OnProgress, if set, is called when a wave starts stopping tasks and
+	// when its replacements are healthy
+	OnProgress func(event RestartEvent)
+	// Revision is the task definition the forced deployment rolls out, see
+	// ValidateRevision; empty keeps the revision the service runs. Kill
+	// ignores it.
+	Revision string
+}
+
+// RestartEvent reports the progress of a restart in waves
+type RestartEvent struct {
+	Wave    int
+	Waves   int
+	Stopped []StoppedTaskInfo
+	Healthy bool
 }
 
 // RestartResult contains the result of a service restart operation
 type RestartResult struct {
 	StoppedTasks   []StoppedTaskInfo
+	StopFailures   []StopTaskFailure
 	ServiceArn     string
 	TaskDefinition string
 	Method         string // "kill" or "force_deploy"
+	Waves          int
+}
+
+// StopTaskFailure is a task that could not be stopped
+type StopTaskFailure struct {
+	TaskArn string
+	Err     error
 }
 
 // StoppedTaskInfo represents information about a stopped task
@@ -103,28 +258,83 @@ type StoppedTaskInfo struct {
 type TaskDefinitionPruneEntry struct {
 	Arn     string
 	DaysOld int
-	Action  string // "kept", "deleted", "skipped"
+	Action  string // "kept", "deleted", "skipped", "deleted-inactive", "kept-inactive"
 	Reason  string
 	Family  string
 }
 
+// PruneOptions configures which task definitions a prune keeps. A revision is
+// kept when it is in use or when any of the retention rules keeps it.
+type PruneOptions struct {
+	KeepLast int
+	KeepDays int
+	// KeepDeployedWithin keeps revisions that were a service's primary
+	// deployment within this window
+	KeepDeployedWithin time.Duration
+	// KeepTagPatterns keeps revisions whose image tag matches a glob
+	KeepTagPatterns []string
+	// KeepPerImage keeps the newest N revisions of every distinct image
+	KeepPerImage int
+	DryRun       bool
+	// DeleteInactive permanently deletes INACTIVE revisions after
+	// deregistration.
+	DeleteInactive bool
+	// AlsoCheckClusters lists clusters besides the service's own whose
+	// services and tasks may use revisions of the pruned families.
+	AlsoCheckClusters []string
+	// OnProgress, when set, is called with the number of task definitions
+	// described so far and the number found so far. It is called from
+	// several goroutines, one call at a time.
+	OnProgress func(done, total int)
+}
+
+// PruneFamilySummary contains the outcome of pruning a single family
+type PruneFamilySummary struct {
+	Family       string
+	TotalCount   int
+	DeletedCount int
+	KeptCount    int
+	SkippedCount int
+	// InactiveDeletedCount is the number of INACTIVE revisions deleted
+	InactiveDeletedCount int
+	Err                  error
+}
+
+// PruneDeleteFailure is an INACTIVE task definition that could not be deleted
+type PruneDeleteFailure struct {
+	Arn    string
+	Family string
+	Reason string
+}
+
 // PruneResult contains the result of a task definition pruning operation
 type PruneResult struct {
-	Families       []string
-	TotalCount     int
-	DeletedCount   int
-	KeptCount      int
-	SkippedCount   int
-	DryRun         bool
-	ProcessedTasks []TaskDefinitionPruneEntry
+	Families        []string
+	FamilySummaries []PruneFamilySummary
+	CheckedClusters []string
+	TotalCount      int
+	DeletedCount    int
+	KeptCount       int
+	SkippedCount    int
+	// InactiveDeletedCount is the number of INACTIVE revisions deleted
+	InactiveDeletedCount int
+	DeleteFailures       []PruneDeleteFailure
+	DryRun               bool
+	ProcessedTasks       []TaskDefinitionPruneEntry
+	// Warnings name the sources of in-use revisions that could not be
+	// checked, such as EventBridge rules without the permission to list them
+	Warnings []string
 }
 
 // TaskInfo represents an ECS task with its details for listing
 type TaskInfo struct {
-	ID          string
-	CPU         string
-	Memory      string
-	RunningTime string
+	ID           string
+	Arn          string
+	CPU          string
+	Memory       string
+	RunningTime  string
+	StartedAt    time.Time
+	HealthStatus string
 }
 
 // ServiceInfo represents an ECS service with its details for listing
@@ -140,6 +350,22 @@ type ClusterInfo struct {
 	Services []ServiceInfo
 }
 
+// ScaleOptions configures how a service is scaled
+type ScaleOptions struct {
+	// AllowZero permits scaling the service to 0 tasks
+	AllowZero bool
+	// UpdateAutoScalingBounds adjusts the min and max capacity of the
+	// service's scalable target so that auto scaling keeps the new count
+	UpdateAutoScalingBounds bool
+}
+
+// AutoScalingBounds is the capacity range of an Application Auto Scaling
+// scalable target
+type AutoScalingBounds struct {
+	MinCapacity int32 `json:"minCapacity"`
+	MaxCapacity int32 `json:"maxCapacity"`
+}
+
 // ScaleResult contains the result of a service scaling operation
 type ScaleResult struct {
 	ServiceArn           string
@@ -147,4 +373,180 @@ type ScaleResult struct {
 	NewDesiredCount      int32
 	ClusterName          string
 	ServiceName          string
+	// AutoScaling holds the scalable target bounds before scaling, nil when
+	// the service is not registered with Application Auto Scaling
+	AutoScaling *AutoScalingBounds
+	// UpdatedAutoScaling holds the new bounds when they were adjusted
+	UpdatedAutoScaling *AutoScalingBounds
+	Warnings           []string
+}
+
+// ServiceRef names a service in a cluster
+type ServiceRef struct {
+	Cluster string
+	Service string
+}
+
+func (r ServiceRef) String() string {
+	return r.Cluster + "/" + r.Service
+}
+
+// ScaleFailure is a service that could not be scaled in a bulk operation
+type ScaleFailure struct {
+	ServiceRef
+	Err error
+}
+
+// BulkScaleResult contains the results of scaling several services
+type BulkScaleResult struct {
+	Results  []ScaleResult
+	Failures []ScaleFailure
+}
+
+// ScheduledScaleOptions describes a scheduled change of a service's auto
+// scaling bounds
+type ScheduledScaleOptions struct {
+	// Name defaults to one derived from the schedule
+	Name string
+	// Schedule is a five-field cron expression ("0 20 * * MON-FRI") or an
+	// Application Auto Scaling expression such as at(...), rate(...) or
+	// cron(...)
+	Schedule string
+	// Timezone is an IANA time zone; UTC when empty
+	Timezone    string
+	MinCapacity *int32
+	MaxCapacity *int32
+}
+
+// ScheduledScaleAction is a scheduled action of a service's scalable target
+type ScheduledScaleAction struct {
+	Name        string
+	Schedule    string
+	Timezone    string
+	MinCapacity *int32
+	MaxCapacity *int32
+	CreatedAt   time.Time
+}
+
+// ScheduleScaleResult contains the result of adding a scheduled action
+type ScheduleScaleResult struct {
+	Action ScheduledScaleAction
+	// RegisteredTarget holds the bounds of the scalable target registered
+	// for the service when it was not yet managed by auto scaling
+	RegisteredTarget *AutoScalingBounds
+}
+
+// ScalingAlarm is a CloudWatch alarm that triggers a scaling policy
+type ScalingAlarm struct {
+	Name   string
+	State  string
+	Reason string
+}
+
+// ScalingPolicy describes an auto scaling policy of a service
+type ScalingPolicy struct {
+	Name             string
+	Type             string
+	Metric           string
+	TargetValue      *float64
+	ScaleInCooldown  *int32
+	ScaleOutCooldown *int32
+	DisableScaleIn   bool
+	Alarms           []ScalingAlarm
+}
+
+// ScalingPoliciesResult contains the auto scaling setup of a service
+type ScalingPoliciesResult struct {
+	// Bounds is nil when the service is not registered with auto scaling
+	Bounds   *AutoScalingBounds
+	Policies []ScalingPolicy
+}
+
+// ServiceStatus is the state of a service with its deployments, tasks, load
+// balancer targets and recent events
+type ServiceStatus struct {
+	Cluster        string                `json:"cluster"`
+	Service        string                `json:"service"`
+	Region         string                `json:"region"`
+	Status         string                `json:"status"`
+	TaskDefinition string                `json:"taskDefinition"`
+	LaunchType     string                `json:"launchType,omitempty"`
+	DesiredCount   int32                 `json:"desiredCount"`
+	RunningCount   int32                 `json:"runningCount"`
+	PendingCount   int32                 `json:"pendingCount"`
+	CircuitBreaker *CircuitBreakerStatus `json:"circuitBreaker,omitempty"`
+	Deployments    []DeploymentStatus    `json:"deployments"`
+	Tasks          []TaskStatus          `json:"tasks"`
+	Targets        []TargetStatus        `json:"targets"`
+	Events         []ServiceEvent        `json:"events"`
+}
+
+// CircuitBreakerStatus is the deployment circuit breaker configuration
+type CircuitBreakerStatus struct {
+	Enabled  bool `json:"enabled"`
+	Rollback bool `json:"rollback"`
+}
+
+// DeploymentStatus is a deployment of a service and its rollout state
+type DeploymentStatus struct {
+	ID                 string    `json:"id"`
+	Status             string    `json:"status"`
+	TaskDefinition     string    `json:"taskDefinition"`
+	DesiredCount       int32     `json:"desiredCount"`
+	RunningCount       int32     `json:"runningCount"`
+	PendingCount       int32     `json:"pendingCount"`
+	FailedTasks        int32     `json:"failedTasks"`
+	RolloutState       string    `json:"rolloutState,omitempty"`
+	RolloutStateReason string    `json:"rolloutStateReason,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+// TaskStatus is a running task of a service
+type TaskStatus struct {
+	ID               string    `json:"id"`
+	TaskDefinition   string    `json:"taskDefinition"`
+	LastStatus       string    `json:"lastStatus"`
+	HealthStatus     string    `json:"healthStatus"`
+	AvailabilityZone string    `json:"availabilityZone"`
+	PrivateIP        string    `json:"privateIp,omitempty"`
+	StartedAt        time.Time `json:"startedAt"`
+	RunningTime      string    `json:"runningTime"`
+}
+
+// TargetStatus is a load balancer target of a service and its health
+type TargetStatus struct {
+	TargetGroupArn string `json:"targetGroupArn"`
+	TargetGroup    string `json:"targetGroup"`
+	Target         string `json:"target"`
+	Port           int32  `json:"port,omitempty"`
+	TaskID         string `json:"taskId,omitempty"`
+	State          string `json:"state"`
+	Reason         string `json:"reason,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+// ServiceEvent is a message from the service's event log
+type ServiceEvent struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Message   string    `json:"message"`
+}
+
+// ClusterOverview is a cluster with the counts and tasks of its services
+type ClusterOverview struct {
+	Cluster  string
+	Services []ServiceOverview
+}
+
+// ServiceOverview is a service with its counts and running tasks
+type ServiceOverview struct {
+	Cluster        string
+	Service        string
+	Status         string
+	TaskDefinition string
+	DesiredCount   int32
+	RunningCount   int32
+	PendingCount   int32
+	RolloutState   string
+	Tasks          []TaskStatus
 }
@@ -0,0 +1,88 @@
+// Copyright (c) Petr Reichl and affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// fuzzyScore scores a case-insensitive subsequence match of pattern in
+// candidate. Consecutive characters and characters at the start of a word
+// (after "/", "-", "_" or ".") score higher, gaps lower.
+func fuzzyScore(pattern, candidate string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	candidate = strings.ToLower(candidate)
+
+	score := 0
+	previous := -2
+	position := 0
+
+	for _, want := range pattern {
+		found := strings.IndexRune(candidate[position:], want)
+		if found < 0 {
+			return 0, false
+		}
+
+		index := position + found
+
+		switch {
+		case index == previous+1:
+			score += 5
+		case index == 0 || strings.ContainsRune("/-_.", rune(candidate[index-1])):
+			score += 3
+		default:
+			score -= min(index-previous, 5)
+		}
+
+		previous = index
+		position = index + utf8.RuneLen(want)
+	}
+
+	return score, true
+}
+
+// FuzzyFilter returns the candidates matching pattern, best matches first.
+// An empty pattern matches every candidate in the original order.
+func FuzzyFilter(pattern string, candidates []string) []string {
+	if pattern == "" {
+		return slices.Clone(candidates)
+	}
+
+	type match struct {
+		candidate string
+		score     int
+	}
+
+	var matches []match
+
+	for _, candidate := range candidates {
+		if score, ok := fuzzyScore(pattern, candidate); ok {
+			matches = append(matches, match{candidate: candidate, score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	filtered := make([]string, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.candidate
+	}
+
+	return filtered
+}
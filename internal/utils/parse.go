@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -64,3 +65,25 @@ func ParseMemory(value string) (string, error) {
 
 	return lower, nil
 }
+
+// ParseDuration parses a duration string like time.ParseDuration, additionally
+// accepting a "d" suffix for whole days (e.g., "7d").
+func ParseDuration(value string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(value)
+
+	if before, ok := strings.CutSuffix(trimmed, "d"); ok {
+		days, err := strconv.Atoi(before)
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q: number of days must be a non-negative integer (e.g., 7d)", value)
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(trimmed)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use a value like 30m, 12h or 7d", value)
+	}
+
+	return duration, nil
+}
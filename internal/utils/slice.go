@@ -13,7 +13,11 @@
 
 package utils
 
-import "errors"
+import (
+	"cmp"
+	"errors"
+	"slices"
+)
 
 // Generic helper functions for safe slice access
 // These functions provide bounds checking to prevent panics when accessing slice elements
@@ -38,3 +42,15 @@ func SafeGetFirst[T any](slice []T, errorMsg string) (T, error) {
 
 	return slice[0], nil
 }
+
+// SortedKeys returns the keys of a map in ascending order.
+func SortedKeys[K cmp.Ordered, V any](values map[K]V) []K {
+	keys := make([]K, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}